@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"github.io/kevin-rd/evm-bench/internal/keys"
+	"log"
+	"os"
+	"sort"
+)
+
+const (
+	envKeys      = "EVM_BENCH_KEYS"       // sender hex keys, separated by commas or whitespace
+	envMnemonic  = "EVM_BENCH_MNEMONIC"   // mnemonic of the sender accounts
+	envFaucetKey = "EVM_BENCH_FAUCET_KEY" // hex key of the faucet
+
+	// devMnemonic well known mnemonic of local dev chains (anvil, hardhat), used without a key source
+	devMnemonic = "test test test test test test test test test test test junk"
+	// defaultDerived accounts derived from a mnemonic or seed without -accounts
+	defaultDerived = 5
+)
+
+// loadAccounts hex private keys of the senders from the one key source given, and its name
+func loadAccounts() ([]string, string, error) {
+	if *numAccounts < 0 {
+		return nil, "", fmt.Errorf("-accounts must not be negative")
+	}
+	envKeyList, err := keys.FromEnv(envKeys)
+	if err != nil {
+		return nil, "", err
+	}
+	words := *mnemonic
+	if words == "" {
+		words = os.Getenv(envMnemonic)
+	}
+
+	var sources []string
+	for name, given := range map[string]bool{
+		"-keystore":   *keystoreDir != "",
+		"-keys":       *keysFile != "",
+		"$" + envKeys: len(envKeyList) > 0,
+		"-mnemonic":   words != "",
+		"-seed":       *seed != "",
+	} {
+		if given {
+			sources = append(sources, name)
+		}
+	}
+	sort.Strings(sources)
+	if len(sources) > 1 {
+		return nil, "", fmt.Errorf("use one key source, got %v", sources)
+	}
+
+	derived := *numAccounts
+	if derived == 0 {
+		derived = defaultDerived
+	}
+	switch {
+	case *keystoreDir != "":
+		loaded, err := keys.FromKeystore(*keystoreDir, *passwordFile)
+		return take(loaded, err, "keystore")
+	case *keysFile != "":
+		loaded, err := keys.FromFile(*keysFile)
+		return take(loaded, err, "keys file")
+	case len(envKeyList) > 0:
+		return take(envKeyList, nil, "env")
+	case words != "":
+		loaded, err := keys.FromMnemonic(words, "", *hdPath, derived)
+		return loaded, "mnemonic", err
+	case *seed != "":
+		return keys.FromSeed(*seed, derived), "seed", nil
+	default:
+		log.Printf("No key source given, using the dev mnemonic, for local dev chains only")
+		loaded, err := keys.FromMnemonic(devMnemonic, "", keys.DefaultHDPath, derived)
+		return loaded, "dev mnemonic", err
+	}
+}
+
+// take the first -accounts of the loaded keys, all if 0
+func take(loaded []string, err error, source string) ([]string, string, error) {
+	if err != nil {
+		return nil, "", err
+	}
+	if *numAccounts > len(loaded) {
+		return nil, "", fmt.Errorf("-accounts %d, only %d keys in %s", *numAccounts, len(loaded), source)
+	}
+	if *numAccounts > 0 {
+		loaded = loaded[:*numAccounts]
+	}
+	return loaded, source, nil
+}
+
+// faucetKeyOf -faucet, or the env
+func faucetKeyOf() string {
+	if *faucetKey != "" {
+		return *faucetKey
+	}
+	return os.Getenv(envFaucetKey)
+}
+
+func hdPathOf(keySource string) string {
+	if keySource == "mnemonic" || keySource == "dev mnemonic" {
+		return *hdPath
+	}
+	return ""
+}
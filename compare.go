@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"os"
+)
+
+// runCompare compares saved result files against the first one, returns the exit code
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s compare [flags] baseline.json result.json [result.json...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	var th statistics.Thresholds
+	fs.Float64Var(&th.MaxTPSDrop, "max-tps-drop", 5, "regression if tps drops more than this %")
+	fs.Float64Var(&th.MaxLatencyIncrease, "max-latency-increase", 10, "regression if an inclusion latency percentile increases more than this %")
+	fs.Float64Var(&th.MaxErrorRateIncrease, "max-error-rate-increase", 1, "regression if error rate increases more than this percentage points")
+	fs.Float64Var(&th.Alpha, "alpha", 0.05, "significance level, changes with a larger p-value are not regressions")
+	allowUntested := fs.Bool("allow-untested", false, "pass metrics exceeding their threshold that could not be tested for significance")
+	_ = fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+
+	base, err := statistics.ReadResult(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	regressed, untested := false, false
+	for _, path := range fs.Args()[1:] {
+		current, err := statistics.ReadResult(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		deltas := statistics.Compare(base, current, th)
+		statistics.PrintDeltas(fmt.Sprintf("%s vs %s", path, fs.Arg(0)), deltas)
+		for _, d := range deltas {
+			regressed = regressed || d.Regressed
+			untested = untested || d.Untested()
+		}
+	}
+	if regressed {
+		fmt.Println("\nregression detected")
+		return 1
+	}
+	if untested && !*allowUntested {
+		fmt.Println("\nthreshold exceeded, not tested, pass -allow-untested to ignore")
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	connIdBase = 1 << 20         // request ids of a Conn, apart from the MethodIds
+	connPing   = headTimeout / 3 // ping period, a pong or any message extends the read deadline
+)
+
+var errClosed = errors.New("connection closed")
+
+// Conn one websocket shared by many senders. Requests are written under a lock and responses
+// are dispatched by id from a single reader, so any number of accounts can be multiplexed.
+type Conn struct {
+	url string
+
+	writeMutex sync.Mutex
+	ws         *websocket.Conn
+
+	mutex     sync.Mutex
+	nextId    int
+	handlers  map[int]func(JSONRPCResponse) // request id -> response handler
+	waitSince time.Time                     // last response, or first request when there were none outstanding
+}
+
+// DialConn connects and starts reading responses
+func DialConn(url string) (*Conn, error) {
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{url: url, ws: ws, nextId: connIdBase, handlers: make(map[int]func(JSONRPCResponse))}
+	keepAlive(ws)
+	go c.read()
+	go c.watch()
+	return c, nil
+}
+
+// keepAlive a pong extends the read deadline, a node gone silent fails the read
+func keepAlive(ws *websocket.Conn) {
+	_ = ws.SetReadDeadline(time.Now().Add(headTimeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(headTimeout))
+	})
+}
+
+// Go writes a request, handle is called from the reader with its response, or with an error
+// response if the request or the connection failed
+func (c *Conn) Go(method string, params []interface{}, handle func(JSONRPCResponse)) {
+	c.mutex.Lock()
+	id := c.nextId
+	c.nextId++
+	if len(c.handlers) == 0 {
+		c.waitSince = time.Now()
+	}
+	c.handlers[id] = handle
+	c.mutex.Unlock()
+
+	c.writeMutex.Lock()
+	err := errClosed
+	if c.ws != nil {
+		_ = c.ws.SetWriteDeadline(time.Now().Add(headTimeout))
+		err = c.ws.WriteJSON(&JSONRPCRequest{Version: DefaultVersion, Method: method, Params: params, ID: id})
+	}
+	c.writeMutex.Unlock()
+	if err != nil {
+		c.mutex.Lock()
+		_, ok := c.handlers[id] // or already failed by the reader
+		delete(c.handlers, id)
+		c.mutex.Unlock()
+		if ok {
+			handle(errorResponse(id, err))
+		}
+	}
+}
+
+// Call writes a request and waits for its response, v may be nil
+func (c *Conn) Call(v any, method string, params ...interface{}) error {
+	done := make(chan JSONRPCResponse, 1)
+	c.Go(method, params, func(resp JSONRPCResponse) { done <- resp })
+	resp := <-done
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %s", method, resp.Error.Message)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, v)
+}
+
+func (c *Conn) call(id MethodId, v any, params ...interface{}) error {
+	return c.Call(v, id.String(), params...)
+}
+
+// read dispatches responses, on a broken connection redials and fails the outstanding requests
+func (c *Conn) read() {
+	for {
+		c.writeMutex.Lock()
+		ws := c.ws
+		c.writeMutex.Unlock()
+		if ws == nil {
+			return
+		}
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			// fail the outstanding requests once redialed, so that retries go to the new connection
+			handlers := c.takeHandlers()
+			redialed := c.redial()
+			failAll(handlers, err)
+			if !redialed {
+				return
+			}
+			continue
+		}
+		_ = ws.SetReadDeadline(time.Now().Add(headTimeout))
+		var resp JSONRPCResponse
+		if err := json.Unmarshal(message, &resp); err != nil {
+			log.Printf("Failed to parse response: %v", err)
+			continue
+		}
+		c.mutex.Lock()
+		c.waitSince = time.Now()
+		handle, ok := c.handlers[resp.ID]
+		delete(c.handlers, resp.ID)
+		c.mutex.Unlock()
+		if ok {
+			handle(resp)
+		}
+	}
+}
+
+// watch pings the node, and breaks the connection if requests are outstanding and no response
+// came for headTimeout, e.g. a node still answering pings but not requests. The reader then
+// fails the outstanding requests and redials.
+func (c *Conn) watch() {
+	ticker := time.NewTicker(connPing)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.mutex.Lock()
+		stalled := len(c.handlers) > 0 && time.Since(c.waitSince) > headTimeout
+		c.mutex.Unlock()
+
+		c.writeMutex.Lock()
+		if c.ws == nil {
+			c.writeMutex.Unlock()
+			return
+		}
+		if stalled {
+			log.Printf("No response from %s for %s, reconnect", c.url, headTimeout)
+			_ = c.ws.Close()
+		} else {
+			_ = c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(headTimeout))
+		}
+		c.writeMutex.Unlock()
+	}
+}
+
+func (c *Conn) takeHandlers() map[int]func(JSONRPCResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	handlers := c.handlers
+	c.handlers = make(map[int]func(JSONRPCResponse))
+	return handlers
+}
+
+func failAll(handlers map[int]func(JSONRPCResponse), err error) {
+	for id, handle := range handlers {
+		handle(errorResponse(id, err))
+	}
+}
+
+// redial false if the connection was closed by Close
+func (c *Conn) redial() bool {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.ws == nil {
+		return false
+	}
+	log.Printf("Connection to %s broken, reconnect", c.url)
+	ws, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+	if err != nil {
+		log.Fatalf("Error ReConn to ws: %v", err)
+	}
+	keepAlive(ws)
+	c.ws = ws
+	return true
+}
+
+func (c *Conn) Close() error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.ws == nil {
+		return nil
+	}
+	ws := c.ws
+	c.ws = nil
+	return ws.Close()
+}
+
+// connError code of the responses made up for requests lost with the connection
+const connError = -1
+
+func errorResponse(id int, err error) JSONRPCResponse {
+	return JSONRPCResponse{Version: DefaultVersion, ID: id, Error: &JSONRPCError{Code: connError, Message: err.Error()}}
+}
@@ -0,0 +1,178 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	transferGas  uint64 = 21000
+	fundBatch           = 200 // funding txs written before waiting for their responses
+	fundAttempts        = 3
+	fundTimeout         = time.Second * 60 // wait for the funding txs to be included
+)
+
+// transfer a funding tx, a rejected one is sent again with the same nonce to fill the gap
+type transfer struct {
+	to    common.Address
+	value *big.Int
+	nonce uint64
+}
+
+// Fund tops up the balance of every account to amount from the account, in batches with
+// locally managed nonces, waits for inclusion and verifies the balances. Rejected txs are sent
+// again into their own nonces, so the txs queued behind them go through. Accounts left short
+// are retried with a fresh nonce.
+func (a *Account) Fund(conn *Conn, accounts []common.Address, amount *big.Int) error {
+	short, err := shortOf(conn, accounts, amount)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; len(short) > 0 && attempt <= fundAttempts; attempt++ {
+		log.Printf("Funding %d accounts, attempt %d", len(short), attempt)
+		nonce, err := nonceAt(conn, a.address, "pending")
+		if err != nil {
+			return err
+		}
+
+		transfers := make([]transfer, len(short))
+		for i, s := range short {
+			transfers[i] = transfer{to: s.address, value: s.missing, nonce: nonce + uint64(i)}
+		}
+		for resend := 0; len(transfers) > 0; resend++ {
+			if resend == fundAttempts {
+				return fmt.Errorf("%d funding txs rejected %d times, e.g. nonce %d", len(transfers), resend, transfers[0].nonce)
+			}
+			if resend > 0 {
+				time.Sleep(time.Second)
+			}
+			if transfers, err = a.send(conn, transfers); err != nil {
+				return err
+			}
+		}
+
+		if err := waitNonce(conn, a.address, nonce+uint64(len(short)), fundTimeout); err != nil {
+			log.Printf("Funding txs not included: %v", err)
+		}
+		if short, err = shortOf(conn, accounts, amount); err != nil {
+			return err
+		}
+	}
+	if len(short) > 0 {
+		return fmt.Errorf("%d accounts still below %s wei, e.g. %s", len(short), amount, short[0].address.Hex())
+	}
+	log.Printf("Funded %d accounts with %s wei", len(accounts), amount)
+	return nil
+}
+
+// send writes the transfers in batches, each tx with a request id of its own, and returns the rejected ones.
+// Txs the node already has are taken as sent, the balances are verified after inclusion anyway.
+func (a *Account) send(conn *Conn, transfers []transfer) ([]transfer, error) {
+	var rejected []transfer
+	for from := 0; from < len(transfers); from += fundBatch {
+		batch := transfers[from:min(from+fundBatch, len(transfers))]
+		errs := make([]*JSONRPCError, len(batch))
+		var wg sync.WaitGroup
+		for i, tr := range batch {
+			rawTx, err := signTransfer(a.privateKey, tr.nonce, tr.to, tr.value)
+			if err != nil {
+				wg.Wait()
+				return nil, err
+			}
+			wg.Add(1)
+			conn.Go(ETH_RawTransaction.String(), []interface{}{hexutil.Encode(rawTx)}, func(resp JSONRPCResponse) {
+				defer wg.Done()
+				errs[i] = resp.Error
+			})
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if err == nil {
+				continue
+			}
+			// the node has it or included it, e.g. accepted before the connection dropped
+			switch statistics.ClassifyError(err.Message) {
+			case "already known", "nonce too low":
+				continue
+			}
+			log.Printf("Funding tx of nonce %d rejected: %v", batch[i].nonce, err.Message)
+			rejected = append(rejected, batch[i])
+		}
+	}
+	return rejected, nil
+}
+
+// shortAccount an account below the funding amount
+type shortAccount struct {
+	address common.Address
+	missing *big.Int
+}
+
+func shortOf(conn *Conn, accounts []common.Address, amount *big.Int) ([]shortAccount, error) {
+	var short []shortAccount
+	for _, account := range accounts {
+		balance, err := balanceAt(conn, account)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Cmp(amount) < 0 {
+			short = append(short, shortAccount{address: account, missing: new(big.Int).Sub(amount, balance)})
+		}
+	}
+	return short, nil
+}
+
+func signTransfer(privateKey *ecdsa.PrivateKey, nonce uint64, to common.Address, value *big.Int) ([]byte, error) {
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    value,
+		Gas:      transferGas,
+		GasPrice: big.NewInt(gasPrice),
+	})
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(big.NewInt(chainID)), privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return signedTx.MarshalBinary()
+}
+
+// waitNonce waits until the latest nonce of account reaches nonce, i.e. all txs before are included
+func waitNonce(conn *Conn, account common.Address, nonce uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		latest, err := nonceAt(conn, account, "latest")
+		if err != nil {
+			return err
+		}
+		if latest >= nonce {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("nonce %d, want %d after %s", latest, nonce, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func nonceAt(conn *Conn, account common.Address, tag string) (uint64, error) {
+	var nonce hexutil.Uint64
+	err := conn.call(ETH_TransactionCount, &nonce, account.Hex(), tag)
+	return uint64(nonce), err
+}
+
+func balanceAt(conn *Conn, account common.Address) (*big.Int, error) {
+	var balance hexutil.Big
+	if err := conn.call(ETH_Balance, &balance, account.Hex(), "latest"); err != nil {
+		return nil, err
+	}
+	return balance.ToInt(), nil
+}
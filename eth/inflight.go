@@ -0,0 +1,63 @@
+package eth
+
+import (
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"sync"
+)
+
+// InFlight submitted txs handed from the senders to the tracker. Add never waits for the
+// tracker, which takes the queued txs in batches, so slow confirmation lookups show up as
+// backlog instead of throttling the senders.
+type InFlight struct {
+	mutex  sync.Mutex
+	queue  []*statistics.TestResult
+	closed bool
+	wake   chan struct{} // signaled on add and close
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{wake: make(chan struct{}, 1)}
+}
+
+// Add a submitted or rejected tx
+func (f *InFlight) Add(res *statistics.TestResult) {
+	f.mutex.Lock()
+	f.queue = append(f.queue, res)
+	f.mutex.Unlock()
+	f.signal()
+}
+
+// Close no more txs will be added, the tracker finishes once the tracked txs are done
+func (f *InFlight) Close() {
+	f.mutex.Lock()
+	f.closed = true
+	f.mutex.Unlock()
+	f.signal()
+}
+
+// Len txs queued and not taken by the tracker yet
+func (f *InFlight) Len() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.queue)
+}
+
+// take waits for queued txs, closed is true once no more will come
+func (f *InFlight) take() (batch []*statistics.TestResult, closed bool) {
+	for {
+		f.mutex.Lock()
+		batch, f.queue, closed = f.queue, nil, f.closed
+		f.mutex.Unlock()
+		if len(batch) > 0 || closed {
+			return
+		}
+		<-f.wake
+	}
+}
+
+func (f *InFlight) signal() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,50 @@
+package eth
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"time"
+)
+
+// BlockNumber latest block number
+func (c *Conn) BlockNumber() (uint64, error) {
+	var num hexutil.Uint64
+	err := c.call(ETH_BlockNumber, &num)
+	return uint64(num), err
+}
+
+// ObserveChain walks blocks from..to of the test window, and records them into chain,
+// independent of what workers sent.
+func (c *Client) ObserveChain(from, to uint64, chain *statistics.ChainStats) error {
+	for num := from; num <= to; num++ {
+		_ = c.ws.SetReadDeadline(time.Now().Add(headTimeout))
+		_ = c.ws.SetWriteDeadline(time.Now().Add(headTimeout))
+		if err := c.WriteJSON(ETH_BlockByNumber, []interface{}{hexutil.Uint64(num), false}); err != nil {
+			return err
+		}
+		var block *Block
+		if err := c.ReadJson(ETH_BlockByNumber.Id(), &block); err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("block %d not found", num)
+		}
+		chain.AddBlock(blockInfo(block))
+	}
+	return nil
+}
+
+func blockInfo(block *Block) statistics.BlockInfo {
+	info := statistics.BlockInfo{
+		Number:    uint64(block.Number),
+		Timestamp: time.Unix(int64(block.Timestamp), 0),
+		Txs:       len(block.Transactions),
+		GasUsed:   uint64(block.GasUsed),
+		GasLimit:  uint64(block.GasLimit),
+	}
+	if block.BaseFee != nil {
+		info.BaseFee = block.BaseFee.ToInt().Uint64()
+	}
+	return info
+}
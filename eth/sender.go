@@ -0,0 +1,190 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Account a sender account, its nonce is managed locally by the one Sender owning it
+type Account struct {
+	Id         int
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+	nonce      uint64
+	resync     atomic.Bool // a tx was rejected, refetch the nonce before the next one
+}
+
+func NewAccount(id int, privateKey string) (*Account, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("account %d: invalid private key", id)
+	}
+	return &Account{Id: id, privateKey: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+func (a *Account) Address() common.Address {
+	return a.address
+}
+
+// Sweep sends the balance of the account, less the gas, back to to, and waits for inclusion
+func (a *Account) Sweep(conn *Conn, to common.Address) error {
+	balance, err := balanceAt(conn, a.address)
+	if err != nil {
+		return err
+	}
+	value := new(big.Int).Sub(balance, new(big.Int).Mul(big.NewInt(gasPrice), new(big.Int).SetUint64(transferGas)))
+	if value.Sign() <= 0 {
+		return nil
+	}
+	nonce, err := nonceAt(conn, a.address, "pending")
+	if err != nil {
+		return err
+	}
+	rawTx, err := signTransfer(a.privateKey, nonce, to, value)
+	if err != nil {
+		return err
+	}
+	if err := conn.call(ETH_RawTransaction, nil, hexutil.Encode(rawTx)); err != nil {
+		return err
+	}
+	return waitNonce(conn, a.address, nonce+1, fundTimeout)
+}
+
+// Sender one sending goroutine. Every second while the mempool has room it sends BatchSize txs,
+// round robin over its accounts, through a connection it may share with other senders.
+type Sender struct {
+	Id       int
+	conn     *Conn
+	rpcAddr  string
+	accounts []*Account
+	to       common.Address
+	next     int            // round robin over accounts
+	acks     sync.WaitGroup // txs written and not answered yet
+
+	Metrics *statistics.Metrics // optional, nil disable
+}
+
+func NewSender(id int, conn *Conn, rpcAddr string, accounts []*Account, recipient string) *Sender {
+	return &Sender{Id: id, conn: conn, rpcAddr: rpcAddr, accounts: accounts, to: common.HexToAddress(recipient)}
+}
+
+// SendTxs sends txs until PressDuration, every submitted or rejected tx is added to txs.
+// It returns once all sent txs are answered.
+func (s *Sender) SendTxs(PressDuration time.Duration, maxPending int, txs *InFlight) error {
+	for _, a := range s.accounts {
+		if err := s.syncNonce(a); err != nil {
+			return err
+		}
+	}
+	log.Printf("Sender %d begin to test, %d accounts", s.Id, len(s.accounts))
+
+	var sent int
+	startTime := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for ; time.Since(startTime) < PressDuration; <-ticker.C {
+		pending := getPending(s.rpcAddr)
+		s.Metrics.SetPending(pending)
+		if maxPending-pending < 200 {
+			continue
+		}
+		for i := 0; i < BatchSize; i++ {
+			if err := s.send(txs); err != nil {
+				log.Printf("Sender %d failed to send tx: %v", s.Id, err)
+				break
+			}
+			sent++
+		}
+	}
+
+	s.acks.Wait()
+	log.Printf("Sender %d exit, sent %d txs", s.Id, sent)
+	return nil
+}
+
+// send the next tx of the next account
+func (s *Sender) send(txs *InFlight) error {
+	a := s.accounts[s.next%len(s.accounts)]
+	s.next++
+	if a.resync.Swap(false) {
+		if err := s.syncNonce(a); err != nil {
+			return err
+		}
+	}
+
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    a.nonce,
+		To:       &s.to,
+		Value:    big.NewInt(123000000000),
+		Gas:      gasLimit,
+		GasPrice: big.NewInt(gasPrice),
+	})
+	signTime := time.Now()
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(big.NewInt(chainID)), a.privateKey)
+	if err != nil {
+		return err
+	}
+	rawTx, err := signedTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r := &statistics.TestResult{
+		ChanId:   a.Id,
+		Nonce:    a.nonce,
+		TxHash:   signedTx.Hash().Hex(),
+		SignTime: signTime,
+		ReqTime:  time.Now(),
+	}
+	a.nonce++
+	s.Metrics.Sent(a.Id, a.nonce)
+
+	s.acks.Add(1)
+	s.conn.Go(ETH_RawTransaction.String(), []interface{}{hexutil.Encode(rawTx)}, func(resp JSONRPCResponse) {
+		defer s.acks.Done()
+		r.AckTime = time.Now()
+		if resp.Error != nil && resp.Error.Code == connError {
+			// the node may have got it before the connection broke, inclusion or the timeout decides
+			a.resync.Store(true)
+			txs.Add(r)
+			return
+		}
+		if resp.Error != nil {
+			r.Failure = statistics.FailRejected
+			r.Error = resp.Error.Message
+			// later nonces of the account would be stuck behind the gap
+			a.resync.Store(true)
+			txs.Add(r)
+			return
+		}
+		var txHex string
+		if err := json.Unmarshal(resp.Result, &txHex); err != nil {
+			log.Printf("Error unmarshaling JSON: %v", err)
+		} else {
+			r.TxHash = txHex
+		}
+		s.Metrics.Accepted()
+		txs.Add(r)
+	})
+	return nil
+}
+
+func (s *Sender) syncNonce(a *Account) error {
+	nonce, err := nonceAt(s.conn, a.address, "pending")
+	if err != nil {
+		return fmt.Errorf("nonce of account %d: %w", a.Id, err)
+	}
+	a.nonce = nonce
+	return nil
+}
@@ -0,0 +1,760 @@
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	headTimeout  = time.Second * 30 // max wait for next message on the subscription
+	recentKeep   = 64               // blocks to remember txs of, for results acked after inclusion
+	lookupIdBase = 1000             // request ids of per tx or per block requests, see tracker.requests
+	maxRetries   = 10               // heads to ask again for a missing receipt before giving up
+)
+
+// inclusion where and when a tx was observed on chain
+type inclusion struct {
+	blockNum  uint64
+	blockHash string
+	blockTime time.Time
+	seenTime  time.Time
+}
+
+// reorgWalk new chain blocks fetched by parent hash, until the common ancestor
+type reorgWalk struct {
+	oldTip uint64
+	blocks []*Block
+}
+
+// request a tracker request with its own id, to know what a null or partial response was for
+type request struct {
+	method MethodId
+	hash   string // tx of eth_getTransactionReceipt and eth_getTransactionByHash
+	block  uint64 // block of eth_getBlockReceipts
+}
+
+// TrackOptions finality settings of the tracker
+type TrackOptions struct {
+	Confirmations uint64        // blocks on top of the inclusion block before a tx counts as confirmed, 0 disable
+	FinalityTag   string        // "safe" or "finalized" block tag to wait for, empty disable
+	Timeout       time.Duration // deadline of each stage, submit to inclusion, to receipt and to finality, 0 disable
+}
+
+// tracker state of txs between submit and finality
+type tracker struct {
+	c     *Client
+	txs   *InFlight
+	stats *statistics.Collector
+	opts  TrackOptions
+
+	mutex    sync.Mutex
+	pending  map[string]*statistics.TestResult // txHash -> result waiting for inclusion
+	awaiting map[string]*statistics.TestResult // txHash -> included result waiting for receipt
+	final    map[string]*statistics.TestResult // txHash -> result with receipt waiting for confirmations/finality
+	late     []string                          // txHashes need a single receipt query
+	retry    []string                          // txHashes with a missing receipt, queried again on the next head
+	tries    map[string]int                    // txHash -> receipts found missing
+	unknown  map[string]int                    // txHash -> lookups of an expired tx left unanswered
+	relookup []string                          // txHashes of unanswered lookups, sent again on the next head
+	requests map[int]request                   // request id -> receipt or lookup request in flight
+	nextId   int
+	included map[string]inclusion // txHash -> recent inclusion not yet matched
+	recent   map[uint64][]string  // blockNum -> tx hashes in included
+	seen     map[uint64]time.Time // blockNum -> local time the head arrived
+	done     bool
+
+	canonical map[uint64]string // blockNum -> hash of recent canonical blocks
+	tip       uint64            // number of the latest head
+	walk      *reorgWalk        // reorg walking back to the common ancestor
+	chain     *statistics.ChainStats
+
+	noBlockReceipts bool // node has no eth_getBlockReceipts, query receipts one by one
+}
+
+// TrackTxs subscribes newHeads and confirms in-flight txs block by block.
+// Results are taken from txs until closed, and recorded into stats once their receipt is fetched,
+// and the confirmations and finality required by opts are reached.
+// Reorgs are recorded into chain.
+func (c *Client) TrackTxs(txs *InFlight, stats *statistics.Collector, chain *statistics.ChainStats, opts TrackOptions) {
+	t := &tracker{
+		c:         c,
+		txs:       txs,
+		stats:     stats,
+		opts:      opts,
+		pending:   make(map[string]*statistics.TestResult),
+		awaiting:  make(map[string]*statistics.TestResult),
+		final:     make(map[string]*statistics.TestResult),
+		tries:     make(map[string]int),
+		unknown:   make(map[string]int),
+		requests:  make(map[int]request),
+		nextId:    lookupIdBase,
+		included:  make(map[string]inclusion),
+		recent:    make(map[uint64][]string),
+		seen:      make(map[uint64]time.Time),
+		canonical: make(map[uint64]string),
+		chain:     chain,
+	}
+
+	go func() {
+		for {
+			batch, closed := txs.take()
+			t.add(batch)
+			if closed {
+				t.mutex.Lock()
+				t.done = true
+				t.mutex.Unlock()
+				return
+			}
+		}
+	}()
+
+	if err := c.subscribeNewHeads(); err != nil {
+		log.Fatalf("Failed to subscribe newHeads: %v", err)
+	}
+
+	for !t.finished() {
+		t.queryLate()
+		t.expire()
+		t.backlog()
+
+		_ = c.ws.SetReadDeadline(time.Now().Add(headTimeout))
+		resp, err := c.ReadResponse()
+		if err != nil {
+			log.Printf("Error reading newHeads: %v", err)
+			_ = c.ReConn()
+			if err := c.subscribeNewHeads(); err != nil {
+				log.Fatalf("Failed to resubscribe newHeads: %v", err)
+			}
+			t.reset()
+			continue
+		}
+
+		switch {
+		case resp.Method == "eth_subscription":
+			var sub SubscriptionResult
+			var head Header
+			if err := json.Unmarshal(resp.Params, &sub); err != nil {
+				log.Printf("Failed to parse subscription: %v", err)
+				continue
+			}
+			if err := json.Unmarshal(sub.Result, &head); err != nil {
+				log.Printf("Failed to parse head: %v", err)
+				continue
+			}
+			t.onHead(&head)
+		case MethodId(resp.ID) == ETH_BlockByNumber:
+			if resp.Error != nil {
+				log.Printf("eth_getBlockByNumber Error: %v", resp.Error.Message)
+				continue
+			}
+			var block Block
+			if err := json.Unmarshal(resp.Result, &block); err != nil {
+				log.Printf("Failed to parse block: %v", err)
+				continue
+			}
+			t.onBlock(&block)
+		case MethodId(resp.ID) == ETH_BlockByHash:
+			if resp.Error != nil {
+				log.Printf("eth_getBlockByHash Error: %v", resp.Error.Message)
+				continue
+			}
+			var block Block
+			if err := json.Unmarshal(resp.Result, &block); err != nil {
+				log.Printf("Failed to parse ancestor block: %v", err)
+				continue
+			}
+			t.onAncestor(&block)
+		case MethodId(resp.ID) == ETH_FinalizedBlock:
+			if resp.Error != nil {
+				log.Printf("eth_getBlockByNumber %s Error: %v", t.opts.FinalityTag, resp.Error.Message)
+				continue
+			}
+			var block Block
+			if err := json.Unmarshal(resp.Result, &block); err != nil {
+				log.Printf("Failed to parse %s block: %v", t.opts.FinalityTag, err)
+				continue
+			}
+			t.onFinalized(uint64(block.Number))
+		case resp.ID >= lookupIdBase:
+			t.onResponse(resp)
+		default:
+			log.Printf("Unexpected message on newHeads subscription: %d %s", resp.ID, resp.Method)
+		}
+	}
+}
+
+// onResponse handles the response of a request with its own id
+func (t *tracker) onResponse(resp JSONRPCResponse) {
+	t.mutex.Lock()
+	req, ok := t.requests[resp.ID]
+	delete(t.requests, resp.ID)
+	t.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	switch req.method {
+	case ETH_BlockReceipts:
+		if resp.Error != nil {
+			// fallback to eth_getTransactionReceipt for all awaiting txs
+			log.Printf("eth_getBlockReceipts Error: %v, query receipts one by one", resp.Error.Message)
+			t.mutex.Lock()
+			t.noBlockReceipts = true
+			for hash := range t.awaiting {
+				t.late = append(t.late, hash)
+			}
+			t.mutex.Unlock()
+			return
+		}
+		var receipts []*Receipt
+		if err := json.Unmarshal(resp.Result, &receipts); err != nil {
+			log.Printf("Failed to parse block receipts: %v", err)
+		}
+		for _, receipt := range receipts {
+			t.onReceipt(receipt)
+		}
+		// null, or receipts not indexed yet for some of ours
+		t.mutex.Lock()
+		var missing []string
+		for hash, res := range t.awaiting {
+			if res.BlockNum == req.block {
+				missing = append(missing, hash)
+			}
+		}
+		t.mutex.Unlock()
+		t.missing(missing...)
+	case ETH_TransactionReceipt:
+		var receipt *Receipt
+		if resp.Error != nil {
+			log.Printf("eth_getTransactionReceipt Error: %v", resp.Error.Message)
+		} else if err := json.Unmarshal(resp.Result, &receipt); err != nil {
+			log.Printf("Failed to parse receipt: %v", err)
+		}
+		if receipt != nil {
+			t.onReceipt(receipt)
+		}
+		t.missing(req.hash)
+	case ETH_TransactionByHash:
+		var tx *Transaction
+		answered := resp.Error == nil
+		if !answered {
+			log.Printf("eth_getTransactionByHash Error: %v", resp.Error.Message)
+		} else if err := json.Unmarshal(resp.Result, &tx); err != nil {
+			log.Printf("Failed to parse tx: %v", err)
+			answered = false
+		}
+		t.onLookup(req.hash, tx, answered)
+	}
+}
+
+// missing queues the receipts of txs still awaiting for the next head, and gives up on
+// txs whose receipt is missing maxRetries times
+func (t *tracker) missing(hashes ...string) {
+	var failed []*statistics.TestResult
+	t.mutex.Lock()
+	for _, hash := range hashes {
+		res, ok := t.awaiting[hash]
+		if !ok {
+			continue
+		}
+		t.tries[hash]++
+		if t.tries[hash] <= maxRetries {
+			t.retry = append(t.retry, hash)
+			continue
+		}
+		delete(t.awaiting, hash)
+		delete(t.tries, hash)
+		res.Failure = statistics.FailTimeout
+		res.Error = fmt.Sprintf("no receipt after %d tries", maxRetries+1)
+		failed = append(failed, res)
+	}
+	t.mutex.Unlock()
+
+	for _, res := range failed {
+		t.stats.Record(res)
+	}
+}
+
+// add submitted txs, they may be already included
+func (t *tracker) add(batch []*statistics.TestResult) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, res := range batch {
+		if res.Failure != "" {
+			// rejected by RPC, nothing to track
+			t.stats.Record(res)
+			continue
+		}
+		if inc, ok := t.included[res.TxHash]; ok {
+			delete(t.included, res.TxHash)
+			confirm(res, inc)
+			t.awaiting[res.TxHash] = res
+			t.late = append(t.late, res.TxHash)
+			continue
+		}
+		t.pending[res.TxHash] = res
+	}
+}
+
+// backlog reports txs queued for and held by the tracker
+func (t *tracker) backlog() {
+	t.mutex.Lock()
+	tracked := len(t.pending) + len(t.awaiting) + len(t.final)
+	t.mutex.Unlock()
+	t.c.Metrics.SetBacklog(t.txs.Len(), tracked)
+}
+
+func (t *tracker) finished() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.done && len(t.pending) == 0 && len(t.awaiting) == 0 && len(t.final) == 0
+}
+
+// expire looks up pending txs passed the deadline, to tell evicted from still in mempool.
+// Included txs get the same deadline for their receipt, and then for confirmations and finality.
+func (t *tracker) expire() {
+	if t.opts.Timeout <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-t.opts.Timeout)
+
+	var hashes []string
+	var failed []*statistics.TestResult
+	t.mutex.Lock()
+	for hash, res := range t.pending {
+		if res.Expired || res.ReqTime.After(deadline) {
+			continue
+		}
+		res.Expired = true
+		hashes = append(hashes, hash)
+	}
+	for hash, res := range t.awaiting {
+		if res.SeenTime.Before(deadline) {
+			delete(t.awaiting, hash)
+			delete(t.tries, hash)
+			res.Failure = statistics.FailTimeout
+			res.Error = fmt.Sprintf("no receipt within %s", t.opts.Timeout)
+			failed = append(failed, res)
+		}
+	}
+	for hash, res := range t.final {
+		if res.ReceiptTime.Before(deadline) {
+			delete(t.final, hash)
+			// a reverted tx stays reverted
+			if res.Failure != statistics.FailReverted {
+				res.Success = false
+				res.Failure = statistics.FailTimeout
+				res.Error = fmt.Sprintf("not final within %s", t.opts.Timeout)
+			}
+			failed = append(failed, res)
+		}
+	}
+	t.mutex.Unlock()
+
+	for _, res := range failed {
+		t.stats.Record(res)
+	}
+	for _, hash := range hashes {
+		t.request(request{method: ETH_TransactionByHash, hash: hash}, hash)
+	}
+}
+
+// reset forgets the requests lost with the connection. Lookups are sent again by expire,
+// receipts of the awaiting txs by queryLate, and blocks missed meanwhile are fetched on the next head.
+func (t *tracker) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.requests = make(map[int]request)
+	for _, res := range t.pending {
+		res.Expired = false
+	}
+	t.late, t.retry, t.relookup = nil, nil, nil
+	for hash := range t.awaiting {
+		t.late = append(t.late, hash)
+	}
+	t.walk = nil
+}
+
+// onLookup drops an expired tx, unless it is included in a block we missed.
+// Unanswered lookups are retried after the next head, up to maxRetries times.
+func (t *tracker) onLookup(hash string, tx *Transaction, answered bool) {
+	t.mutex.Lock()
+	res, pending := t.pending[hash]
+	if !pending {
+		t.mutex.Unlock()
+		return
+	}
+	if !answered {
+		t.unknown[hash]++
+		if t.unknown[hash] <= maxRetries {
+			// Expired until the next head, no lookups in a loop on a node refusing them
+			t.relookup = append(t.relookup, hash)
+			t.mutex.Unlock()
+			return
+		}
+		delete(t.pending, hash)
+		delete(t.unknown, hash)
+		res.Failure = statistics.FailTimeout
+		res.Error = fmt.Sprintf("lookup unanswered %d times", maxRetries+1)
+		t.mutex.Unlock()
+		t.stats.Record(res)
+		return
+	}
+	if tx != nil && tx.BlockNumber != nil {
+		// fetch the block again, onBlock will match it
+		t.relookup = append(t.relookup, hash)
+		t.mutex.Unlock()
+		t.write(ETH_BlockByNumber, *tx.BlockNumber, false)
+		return
+	}
+
+	delete(t.pending, hash)
+	delete(t.unknown, hash)
+	if tx == nil {
+		res.Failure = statistics.FailEvicted
+	} else {
+		res.Failure = statistics.FailTimeout
+	}
+	t.mutex.Unlock()
+	t.stats.Record(res)
+}
+
+// onHead follows the canonical chain, updates confirmations, and fetches the block
+func (t *tracker) onHead(head *Header) {
+	now := time.Now()
+	num := uint64(head.Number)
+	t.seen[num] = now
+
+	var walkFrom string
+	t.mutex.Lock()
+	oldHash, replaced := t.canonical[num]
+	parentHash, hasParent := t.canonical[num-1]
+	forked := hasParent && parentHash != head.ParentHash
+	if (replaced && oldHash != head.Hash) || forked {
+		// the new head is the tip, blocks above it are orphaned
+		oldTip := t.tip
+		for n := range t.canonical {
+			if n >= num {
+				delete(t.canonical, n)
+			}
+		}
+		t.canonical[num] = head.Hash
+		if forked {
+			// parent is replaced too, walk back to the common ancestor
+			if t.walk == nil || t.walk.oldTip < oldTip {
+				t.walk = &reorgWalk{oldTip: oldTip}
+			}
+			walkFrom = head.ParentHash
+		} else {
+			t.reorg(num-1, oldTip)
+		}
+	}
+	// heads skipped by the node or missed while reconnecting, fetch their blocks too
+	var skipped []uint64
+	if t.tip > 0 && num > t.tip+1 {
+		for n := t.tip + 1; n < num; n++ {
+			t.seen[n] = now
+			skipped = append(skipped, n)
+		}
+	}
+	t.canonical[num] = head.Hash
+	t.tip = num
+	t.late = append(t.late, t.retry...)
+	t.retry = nil
+	for _, hash := range t.relookup {
+		if res, ok := t.pending[hash]; ok {
+			res.Expired = false
+		}
+	}
+	t.relookup = nil
+	delete(t.canonical, num-recentKeep)
+
+	if t.opts.Confirmations > 0 {
+		for _, res := range t.final {
+			if res.ConfirmedTime.IsZero() && num >= res.BlockNum+t.opts.Confirmations {
+				res.ConfirmedTime = now
+			}
+		}
+	}
+	done := t.takeFinal()
+	t.mutex.Unlock()
+
+	for _, res := range done {
+		t.stats.Record(res)
+	}
+
+	if walkFrom != "" {
+		t.write(ETH_BlockByHash, walkFrom, false)
+	}
+	// fetch tx hashes of the new block
+	for _, n := range skipped {
+		t.write(ETH_BlockByNumber, hexutil.Uint64(n), false)
+	}
+	t.write(ETH_BlockByNumber, head.Number, false)
+	if t.opts.FinalityTag != "" {
+		t.write(ETH_FinalizedBlock, t.opts.FinalityTag, false)
+	}
+}
+
+// onAncestor steps the reorg walk with a block of the new chain, until the common ancestor
+func (t *tracker) onAncestor(block *Block) {
+	num := uint64(block.Number)
+	t.mutex.Lock()
+	w := t.walk
+	if w == nil {
+		t.mutex.Unlock()
+		return
+	}
+	if hash, ok := t.canonical[num]; ok && hash != block.Hash {
+		// replaced block, continue with its parent
+		t.canonical[num] = block.Hash
+		w.blocks = append(w.blocks, block)
+		t.mutex.Unlock()
+		t.write(ETH_BlockByHash, block.ParentHash, false)
+		return
+	}
+
+	// common ancestor found, or out of the remembered blocks
+	t.walk = nil
+	t.reorg(num, w.oldTip)
+	t.mutex.Unlock()
+
+	// txs of ours may be included in the new chain
+	for i := len(w.blocks) - 1; i >= 0; i-- {
+		t.onBlock(w.blocks[i])
+	}
+}
+
+// reorg knocks our txs out of blocks above ancestor which are not canonical any more, must hold mutex
+func (t *tracker) reorg(ancestor, oldTip uint64) {
+	var knocked []string
+	for _, txs := range []map[string]*statistics.TestResult{t.awaiting, t.final} {
+		for txHash, res := range txs {
+			if res.BlockNum > ancestor && t.canonical[res.BlockNum] != res.BlockHash {
+				delete(txs, txHash)
+				delete(t.tries, txHash)
+				unconfirm(res)
+				t.pending[txHash] = res
+				knocked = append(knocked, txHash)
+			}
+		}
+	}
+	for n, hashes := range t.recent {
+		if n <= ancestor {
+			continue
+		}
+		var kept []string
+		for _, hash := range hashes {
+			if inc, ok := t.included[hash]; ok && t.canonical[n] != inc.blockHash {
+				delete(t.included, hash)
+			} else {
+				kept = append(kept, hash)
+			}
+		}
+		t.recent[n] = kept
+	}
+
+	// knocked txs may be in blocks of the new chain fetched already, e.g. the new head
+	var rematched int
+	for _, hash := range knocked {
+		if inc, ok := t.included[hash]; ok {
+			res := t.pending[hash]
+			delete(t.pending, hash)
+			delete(t.included, hash)
+			confirm(res, inc)
+			t.awaiting[hash] = res
+			t.late = append(t.late, hash)
+			rematched++
+		}
+	}
+
+	var depth uint64
+	if oldTip > ancestor {
+		depth = oldTip - ancestor
+	}
+	t.chain.AddReorg(depth, len(knocked))
+	log.Printf("Reorg after block %d, depth: %d, %d txs of ours back to pending, %d of them in the new chain", ancestor, depth, len(knocked), rematched)
+}
+
+// onFinalized marks txs at or below the safe/finalized block
+func (t *tracker) onFinalized(num uint64) {
+	now := time.Now()
+	t.mutex.Lock()
+	for _, res := range t.final {
+		if res.FinalizedTime.IsZero() && res.BlockNum <= num {
+			res.FinalizedTime = now
+		}
+	}
+	done := t.takeFinal()
+	t.mutex.Unlock()
+
+	for _, res := range done {
+		t.stats.Record(res)
+	}
+}
+
+// takeFinal removes txs reached required confirmations and finality, must hold mutex
+func (t *tracker) takeFinal() (done []*statistics.TestResult) {
+	for hash, res := range t.final {
+		if t.opts.Confirmations > 0 && res.ConfirmedTime.IsZero() {
+			continue
+		}
+		if t.opts.FinalityTag != "" && res.FinalizedTime.IsZero() {
+			continue
+		}
+		delete(t.final, hash)
+		done = append(done, res)
+	}
+	return
+}
+
+// onBlock matches pending txs in block, and requests their receipts
+func (t *tracker) onBlock(block *Block) {
+	num := uint64(block.Number)
+	seenTime, ok := t.seen[num]
+	if !ok {
+		seenTime = time.Now()
+	}
+	delete(t.seen, num)
+	inc := inclusion{blockNum: num, blockHash: block.Hash, blockTime: time.Unix(int64(block.Timestamp), 0), seenTime: seenTime}
+
+	t.mutex.Lock()
+	var ours int
+	for _, hash := range block.Transactions {
+		if res, ok := t.pending[hash]; ok {
+			delete(t.pending, hash)
+			delete(t.unknown, hash)
+			confirm(res, inc)
+			t.awaiting[hash] = res
+			if t.noBlockReceipts {
+				t.late = append(t.late, hash)
+			}
+			ours++
+		} else {
+			t.included[hash] = inc
+			t.recent[num] = append(t.recent[num], hash)
+		}
+	}
+	// forget txs of old blocks, they are not ours
+	for n, hashes := range t.recent {
+		if n+recentKeep < num {
+			for _, hash := range hashes {
+				delete(t.included, hash)
+			}
+			delete(t.recent, n)
+		}
+	}
+	noBlockReceipts := t.noBlockReceipts
+	t.mutex.Unlock()
+
+	if ours > 0 && !noBlockReceipts {
+		t.request(request{method: ETH_BlockReceipts, block: num}, block.Number)
+	}
+}
+
+// onReceipt completes an awaiting tx, and sends it to statistics unless waiting finality
+func (t *tracker) onReceipt(receipt *Receipt) {
+	t.mutex.Lock()
+	res, ok := t.awaiting[receipt.TransactionHash]
+	if !ok || res.BlockHash != receipt.BlockHash {
+		// not ours, or stale receipt from a reorged block
+		t.mutex.Unlock()
+		return
+	}
+	delete(t.awaiting, receipt.TransactionHash)
+	delete(t.tries, receipt.TransactionHash)
+
+	res.Success = uint64(receipt.Status) == 1
+	if !res.Success {
+		res.Failure = statistics.FailReverted
+	}
+	res.GasUsed = uint64(receipt.GasUsed)
+	if receipt.EffectiveGasPrice != nil {
+		res.GasPrice = receipt.EffectiveGasPrice.ToInt().Uint64()
+	}
+	res.Logs = len(receipt.Logs)
+	res.ReceiptTime = time.Now()
+	if t.opts.Confirmations > 0 || t.opts.FinalityTag != "" {
+		t.final[receipt.TransactionHash] = res
+		t.mutex.Unlock()
+		return
+	}
+	t.mutex.Unlock()
+	t.stats.Record(res)
+}
+
+// queryLate requests receipts one by one, for txs acked after their block or without eth_getBlockReceipts
+func (t *tracker) queryLate() {
+	t.mutex.Lock()
+	late := t.late
+	t.late = nil
+	t.mutex.Unlock()
+
+	for _, hash := range late {
+		t.request(request{method: ETH_TransactionReceipt, hash: hash}, hash)
+	}
+}
+
+// request writes req with its own id
+func (t *tracker) request(req request, params ...interface{}) {
+	t.mutex.Lock()
+	id := t.nextId
+	t.nextId++
+	t.requests[id] = req
+	t.mutex.Unlock()
+
+	_ = t.c.ws.SetWriteDeadline(time.Now().Add(headTimeout))
+	if err := t.c.WriteJSONRaw(id, req.method.String(), params); err != nil {
+		log.Printf("Failed to send %s: %v", req.method, err)
+	}
+}
+
+func (t *tracker) write(id MethodId, params ...interface{}) {
+	_ = t.c.ws.SetWriteDeadline(time.Now().Add(headTimeout))
+	if err := t.c.WriteJSON(id, params); err != nil {
+		log.Printf("Failed to send %s: %v", id, err)
+	}
+}
+
+func (c *Client) subscribeNewHeads() error {
+	if err := c.WriteJSON(ETH_Subscribe, []interface{}{"newHeads"}); err != nil {
+		return err
+	}
+	var subId string
+	if err := c.ReadJson(ETH_Subscribe.Id(), &subId); err != nil {
+		return err
+	}
+	if subId == "" {
+		return fmt.Errorf("empty subscription id")
+	}
+	log.Printf("Subscribed newHeads: %s", subId)
+	return nil
+}
+
+func confirm(res *statistics.TestResult, inc inclusion) {
+	res.BlockNum = inc.blockNum
+	res.BlockHash = inc.blockHash
+	res.BlockTime = inc.blockTime
+	res.SeenTime = inc.seenTime
+	res.Cost = inc.seenTime.Sub(res.ReqTime)
+}
+
+// unconfirm resets a tx knocked out of its block by a reorg
+func unconfirm(res *statistics.TestResult) {
+	res.BlockNum = 0
+	res.BlockHash = ""
+	res.BlockTime = time.Time{}
+	res.SeenTime = time.Time{}
+	res.ConfirmedTime = time.Time{}
+	res.FinalizedTime = time.Time{}
+	res.Cost = 0
+	res.Success = false
+	res.Failure = ""
+	res.Reorged++
+}
@@ -0,0 +1,319 @@
+package eth
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+	"github.io/kevin-rd/evm-bench/internal/statistics"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeChain a node serving a scripted chain over websocket, requests are answered in order
+type fakeChain struct {
+	t     *testing.T
+	mutex sync.Mutex
+	ws    *websocket.Conn
+	sub   bool
+
+	blocks    map[string]*Block // hash -> block
+	canonical map[uint64]string // number -> hash
+	reverted  map[string]bool   // txHash -> receipt status 0
+	lookup    func(hash string) (any, *JSONRPCError)
+}
+
+func newFakeChain(t *testing.T) *fakeChain {
+	return &fakeChain{t: t, blocks: make(map[string]*Block), canonical: make(map[uint64]string), reverted: make(map[string]bool)}
+}
+
+// add a block, canonical at its number
+func (f *fakeChain) add(num uint64, hash, parent string, txs ...string) *Block {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	b := &Block{Number: hexutil.Uint64(num), Hash: hash, ParentHash: parent, Timestamp: hexutil.Uint64(time.Now().Unix()), Transactions: append([]string{}, txs...)}
+	f.blocks[hash] = b
+	f.canonical[num] = hash
+	return b
+}
+
+// head pushes a newHeads notification of the block
+func (f *fakeChain) head(b *Block) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.sub {
+		f.t.Fatal("head before subscribe")
+	}
+	f.write(map[string]any{"jsonrpc": "2.0", "method": "eth_subscription", "params": map[string]any{"subscription": "0x1",
+		"result": Header{Number: b.Number, Hash: b.Hash, ParentHash: b.ParentHash, Timestamp: b.Timestamp}}})
+}
+
+// write must hold mutex
+func (f *fakeChain) write(v any) {
+	if f.ws != nil {
+		_ = f.ws.WriteJSON(v)
+	}
+}
+
+func (f *fakeChain) receipt(hash string, b *Block) map[string]any {
+	status := "0x1"
+	if f.reverted[hash] {
+		status = "0x0"
+	}
+	return map[string]any{"transactionHash": hash, "blockHash": b.Hash, "blockNumber": b.Number, "status": status, "gasUsed": "0x5208"}
+}
+
+func (f *fakeChain) serve(w http.ResponseWriter, r *http.Request) {
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	f.mutex.Lock()
+	f.ws = ws
+	f.mutex.Unlock()
+	for {
+		var req JSONRPCRequest
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+		f.mutex.Lock()
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": nil}
+		switch req.Method {
+		case "eth_subscribe":
+			f.sub = true
+			resp["result"] = "0x1"
+		case "eth_getBlockByNumber":
+			var num hexutil.Uint64
+			if err := num.UnmarshalText([]byte(fmt.Sprint(req.Params[0]))); err == nil {
+				resp["result"] = f.blocks[f.canonical[uint64(num)]]
+			}
+		case "eth_getBlockByHash":
+			resp["result"] = f.blocks[fmt.Sprint(req.Params[0])]
+		case "eth_getBlockReceipts":
+			var num hexutil.Uint64
+			_ = num.UnmarshalText([]byte(fmt.Sprint(req.Params[0])))
+			if b := f.blocks[f.canonical[uint64(num)]]; b != nil {
+				receipts := []any{}
+				for _, hash := range b.Transactions {
+					receipts = append(receipts, f.receipt(hash, b))
+				}
+				resp["result"] = receipts
+			}
+		case "eth_getTransactionReceipt":
+			for _, hash := range f.canonical {
+				b := f.blocks[hash]
+				for _, tx := range b.Transactions {
+					if tx == req.Params[0] {
+						resp["result"] = f.receipt(tx, b)
+					}
+				}
+			}
+		case "eth_getTransactionByHash":
+			if f.lookup != nil {
+				result, rpcErr := f.lookup(fmt.Sprint(req.Params[0]))
+				if rpcErr != nil {
+					delete(resp, "result")
+					resp["error"] = rpcErr
+				} else {
+					resp["result"] = result
+				}
+			}
+		}
+		f.write(resp)
+		f.mutex.Unlock()
+	}
+}
+
+// trackRun runs the tracker against the fake chain, script pushes the heads
+type trackRun struct {
+	t      *testing.T
+	trace  string
+	done   chan struct{}
+	result *statistics.Result
+}
+
+func startTracker(t *testing.T, f *fakeChain, opts TrackOptions, hashes ...string) *trackRun {
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	c, err := NewClient("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	trace := filepath.Join(t.TempDir(), "trace.jsonl")
+	chain := statistics.NewChainStats()
+	stats := statistics.NewCollector(1, chain, statistics.Options{Quiet: true, TracePath: trace})
+	txs := NewInFlight()
+	now := time.Now()
+	for _, hash := range hashes {
+		txs.Add(&statistics.TestResult{TxHash: hash, SignTime: now, ReqTime: now, AckTime: now})
+	}
+	txs.Close()
+
+	run := &trackRun{t: t, trace: trace, done: make(chan struct{})}
+	go func() {
+		defer close(run.done)
+		c.TrackTxs(txs, stats, chain, opts)
+		run.result = stats.Close()
+	}()
+	// wait for the subscription
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		f.mutex.Lock()
+		sub := f.sub
+		f.mutex.Unlock()
+		if sub {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no subscription")
+		}
+	}
+	return run
+}
+
+// wait for the tracker to finish and returns the trace records by tx hash
+func (r *trackRun) wait() map[string]traceLine {
+	select {
+	case <-r.done:
+	case <-time.After(10 * time.Second):
+		r.t.Fatal("tracker did not finish")
+	}
+	file, err := os.Open(r.trace)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	defer file.Close()
+	lines := make(map[string]traceLine)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line traceLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			r.t.Fatal(err)
+		}
+		lines[line.Hash] = line
+	}
+	return lines
+}
+
+// traceLine fields of the trace log checked here
+type traceLine struct {
+	Hash          string    `json:"hash"`
+	Block         uint64    `json:"block"`
+	BlockHash     string    `json:"blockHash"`
+	InclusionTime time.Time `json:"inclusionTime"`
+	Success       bool      `json:"success"`
+	Failure       string    `json:"failure"`
+	Reorged       int       `json:"reorged"`
+}
+
+func TestTrackerForkRematch(t *testing.T) {
+	f := newFakeChain(t)
+	b1 := f.add(1, "0x01", "0x00")
+	b2a := f.add(2, "0x2a", b1.Hash, "0xaa")
+	run := startTracker(t, f, TrackOptions{Confirmations: 2}, "0xaa")
+
+	f.head(b1)
+	f.head(b2a)
+	time.Sleep(100 * time.Millisecond)
+
+	// 2b and 3b replace 2a, only the head 3b is pushed, the tx moves to 3b
+	b2b := f.add(2, "0x2b", b1.Hash)
+	b3b := f.add(3, "0x3b", b2b.Hash, "0xaa")
+	f.head(b3b)
+	time.Sleep(100 * time.Millisecond)
+	f.head(f.add(4, "0x4b", b3b.Hash))
+	time.Sleep(50 * time.Millisecond)
+	f.head(f.add(5, "0x5b", "0x4b"))
+
+	line, ok := run.wait()["0xaa"]
+	if !ok {
+		t.Fatal("tx not recorded")
+	}
+	if !line.Success || line.BlockHash != b3b.Hash || line.Block != 3 || line.Reorged != 1 {
+		t.Errorf("got %+v, want success in block 3 %s, reorged once", line, b3b.Hash)
+	}
+}
+
+func TestTrackerSkippedHeads(t *testing.T) {
+	f := newFakeChain(t)
+	b1 := f.add(1, "0x01", "0x00")
+	run := startTracker(t, f, TrackOptions{}, "0xaa", "0xbb")
+
+	f.head(b1)
+	time.Sleep(50 * time.Millisecond)
+	// no heads for 2 and 3
+	b2 := f.add(2, "0x02", b1.Hash, "0xaa")
+	b3 := f.add(3, "0x03", b2.Hash, "0xbb")
+	pushed := time.Now()
+	f.head(f.add(4, "0x04", b3.Hash))
+
+	lines := run.wait()
+	for hash, num := range map[string]uint64{"0xaa": 2, "0xbb": 3} {
+		line := lines[hash]
+		if !line.Success || line.Block != num {
+			t.Errorf("%s: got %+v, want success in block %d", hash, line, num)
+		}
+		if line.InclusionTime.Before(pushed) || line.InclusionTime.After(pushed.Add(time.Second)) {
+			t.Errorf("%s: inclusion time %s, want the arrival of head 4 %s", hash, line.InclusionTime, pushed)
+		}
+	}
+}
+
+func TestTrackerLookupRefused(t *testing.T) {
+	f := newFakeChain(t)
+	var lookups int
+	f.lookup = func(string) (any, *JSONRPCError) {
+		lookups++
+		return nil, &JSONRPCError{Code: -32005, Message: "rate limited"}
+	}
+	parent := "0x00"
+	run := startTracker(t, f, TrackOptions{Timeout: 50 * time.Millisecond}, "0xaa")
+	go func() {
+		for n := uint64(1); n <= 100; n++ {
+			b := f.add(n, fmt.Sprintf("0x%02x", n), parent)
+			parent = b.Hash
+			f.head(b)
+			select {
+			case <-run.done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	line := run.wait()["0xaa"]
+	if line.Failure != string(statistics.FailTimeout) {
+		t.Errorf("got %+v, want timeout", line)
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if lookups != maxRetries+1 {
+		t.Errorf("%d lookups, want %d", lookups, maxRetries+1)
+	}
+}
+
+func TestTrackerFinalityTimeout(t *testing.T) {
+	f := newFakeChain(t)
+	f.reverted["0xbb"] = true
+	b1 := f.add(1, "0x01", "0x00", "0xaa", "0xbb")
+	// the finalized tag never resolves, both time out waiting for finality
+	run := startTracker(t, f, TrackOptions{FinalityTag: "finalized", Timeout: 100 * time.Millisecond}, "0xaa", "0xbb")
+	f.head(b1)
+	time.Sleep(150 * time.Millisecond)
+	f.head(f.add(2, "0x02", b1.Hash))
+
+	lines := run.wait()
+	for hash, want := range map[string]statistics.FailReason{"0xaa": statistics.FailTimeout, "0xbb": statistics.FailReverted} {
+		if line := lines[hash]; line.Failure != string(want) {
+			t.Errorf("%s: got %+v, want %s", hash, line, want)
+		}
+	}
+}
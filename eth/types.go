@@ -14,9 +14,18 @@ const (
 type MethodId int
 
 const (
-	ETH_TXPoolStatus     MethodId = 0
-	ETH_RawTransaction   MethodId = 1
-	ETH_TransactionCount MethodId = 3
+	ETH_TXPoolStatus       MethodId = 0
+	ETH_RawTransaction     MethodId = 1
+	ETH_TransactionCount   MethodId = 3
+	ETH_BlockByNumber      MethodId = 5
+	ETH_Subscribe          MethodId = 6
+	ETH_BlockReceipts      MethodId = 7
+	ETH_TransactionReceipt MethodId = 8
+	ETH_FinalizedBlock     MethodId = 9 // eth_getBlockByNumber with safe/finalized tag
+	ETH_BlockByHash        MethodId = 10
+	ETH_BlockNumber        MethodId = 11
+	ETH_Balance            MethodId = 12
+	ETH_TransactionByHash  MethodId = 13
 )
 
 func (i MethodId) String() string {
@@ -27,6 +36,22 @@ func (i MethodId) String() string {
 		return "eth_getTransactionCount"
 	case ETH_RawTransaction:
 		return "eth_sendRawTransaction"
+	case ETH_BlockByNumber, ETH_FinalizedBlock:
+		return "eth_getBlockByNumber"
+	case ETH_Subscribe:
+		return "eth_subscribe"
+	case ETH_BlockByHash:
+		return "eth_getBlockByHash"
+	case ETH_BlockNumber:
+		return "eth_blockNumber"
+	case ETH_BlockReceipts:
+		return "eth_getBlockReceipts"
+	case ETH_TransactionReceipt:
+		return "eth_getTransactionReceipt"
+	case ETH_Balance:
+		return "eth_getBalance"
+	case ETH_TransactionByHash:
+		return "eth_getTransactionByHash"
 	default:
 		return fmt.Sprintf("unknown MethodId: %d", i)
 	}
@@ -43,12 +68,20 @@ type JSONRPCRequest struct {
 	ID      int           `json:"id"`
 }
 
-// JSONRPCResponse JSON-RPC response structure
+// JSONRPCResponse JSON-RPC response structure, also carries subscription notifications
 type JSONRPCResponse struct {
 	Version string          `json:"jsonrpc"`
 	ID      int             `json:"id"`
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *JSONRPCError   `json:"error,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// SubscriptionResult is the params of an eth_subscription notification
+type SubscriptionResult struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
 }
 
 type JSONRPCError struct {
@@ -69,8 +102,32 @@ type Transaction struct {
 	Nonce       hexutil.Uint64  `json:"nonce"`
 }
 
+// Header is the block header pushed by the newHeads subscription
+type Header struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       string         `json:"hash"`
+	ParentHash string         `json:"parentHash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+}
+
 type Block struct {
-	Number    hexutil.Uint64 `json:"number"`
-	Timestamp hexutil.Uint64 `json:"timestamp"`
-	Hash      string         `json:"hash"`
+	Number       hexutil.Uint64 `json:"number"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Hash         string         `json:"hash"`
+	ParentHash   string         `json:"parentHash"`
+	GasUsed      hexutil.Uint64 `json:"gasUsed"`
+	GasLimit     hexutil.Uint64 `json:"gasLimit"`
+	BaseFee      *hexutil.Big   `json:"baseFeePerGas"`
+	Transactions []string       `json:"transactions"`
+}
+
+// Receipt is the receipt of an included transaction
+type Receipt struct {
+	TransactionHash   string            `json:"transactionHash"`
+	BlockHash         string            `json:"blockHash"`
+	BlockNumber       hexutil.Uint64    `json:"blockNumber"`
+	Status            hexutil.Uint64    `json:"status"`
+	GasUsed           hexutil.Uint64    `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big      `json:"effectiveGasPrice"`
+	Logs              []json.RawMessage `json:"logs"`
 }
@@ -0,0 +1,107 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"math/big"
+	"strings"
+)
+
+// DefaultHDPath base path of the accounts, the account index is appended
+const DefaultHDPath = "m/44'/60'/0'/0"
+
+var curveN = crypto.S256().Params().N
+
+// FromMnemonic derives n hex private keys at basePath/0 .. basePath/n-1 from a BIP-39 mnemonic.
+// The mnemonic is not checked against the wordlist, any phrase gives a reproducible seed.
+func FromMnemonic(mnemonic, passphrase, basePath string, n int) ([]string, error) {
+	base, err := accounts.ParseDerivationPath(basePath)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed := pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+
+	// BIP-32 master key
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	master := extendedKey{key: new(big.Int).SetBytes(sum[:32]), chainCode: sum[32:]}
+	if master.key.Sign() == 0 || master.key.Cmp(curveN) >= 0 {
+		return nil, fmt.Errorf("invalid master key, use another mnemonic")
+	}
+
+	parent := master
+	for _, index := range base {
+		if parent, err = parent.child(index); err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		child, err := parent.child(uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("account %d: %w", i, err)
+		}
+		keys[i] = hex.EncodeToString(crypto.FromECDSA(child.ecdsa()))
+	}
+	return keys, nil
+}
+
+// FromSeed derives n hex private keys as keccak256(seed || index), for quick reproducible scenarios
+func FromSeed(seed string, n int) []string {
+	keys := make([]string, 0, n)
+	var index [8]byte
+	for i := uint64(0); len(keys) < n; i++ {
+		binary.BigEndian.PutUint64(index[:], i)
+		k := new(big.Int).SetBytes(crypto.Keccak256([]byte(seed), index[:]))
+		if k.Sign() == 0 || k.Cmp(curveN) >= 0 {
+			// out of the curve order, practically never
+			continue
+		}
+		keys = append(keys, hex.EncodeToString(crypto.FromECDSA(crypto.ToECDSAUnsafe(k.FillBytes(make([]byte, 32))))))
+	}
+	return keys
+}
+
+// extendedKey BIP-32 private extended key
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+func (k extendedKey) ecdsa() *ecdsa.PrivateKey {
+	return crypto.ToECDSAUnsafe(k.key.FillBytes(make([]byte, 32)))
+}
+
+// child private derivation, hardened if index >= 2^31
+func (k extendedKey) child(index uint32) (extendedKey, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0}, k.key.FillBytes(make([]byte, 32))...)
+	} else {
+		data = crypto.CompressPubkey(&k.ecdsa().PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(curveN) >= 0 {
+		return extendedKey{}, fmt.Errorf("invalid child key at index %d", index)
+	}
+	key := il.Add(il, k.key)
+	key.Mod(key, curveN)
+	if key.Sign() == 0 {
+		return extendedKey{}, fmt.Errorf("invalid child key at index %d", index)
+	}
+	return extendedKey{key: key, chainCode: sum[32:]}, nil
+}
@@ -0,0 +1,76 @@
+package keys
+
+import (
+	"testing"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestFromMnemonic(t *testing.T) {
+	// well known dev accounts of the mnemonic, m/44'/60'/0'/0/0..2
+	want := []string{
+		"ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+		"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+		"5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a",
+	}
+	keys, err := FromMnemonic(testMnemonic, "", DefaultHDPath, len(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		if key != want[i] {
+			t.Errorf("account %d: got %s, want %s", i, key, want[i])
+		}
+	}
+
+	// extra whitespace gives the same seed
+	spaced, err := FromMnemonic("  test test test test test test\ttest test test test test junk\n", "", DefaultHDPath, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spaced[0] != want[0] {
+		t.Errorf("spaced mnemonic: got %s, want %s", spaced[0], want[0])
+	}
+
+	// passphrase and path change the keys
+	for _, c := range []struct{ passphrase, path string }{{"secret", DefaultHDPath}, {"", "m/44'/60'/1'/0"}} {
+		other, err := FromMnemonic(testMnemonic, c.passphrase, c.path, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other[0] == want[0] {
+			t.Errorf("passphrase %q path %s: same key as the default", c.passphrase, c.path)
+		}
+	}
+
+	if _, err := FromMnemonic(testMnemonic, "", "m/x", 1); err == nil {
+		t.Error("invalid path: no error")
+	}
+}
+
+func TestFromSeed(t *testing.T) {
+	a, b := FromSeed("bench", 4), FromSeed("bench", 4)
+	if len(a) != 4 {
+		t.Fatalf("got %d keys, want 4", len(a))
+	}
+	seen := make(map[string]bool)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("key %d not deterministic: %s != %s", i, a[i], b[i])
+		}
+		if err := check(a[i]); err != nil {
+			t.Errorf("key %d: %v", i, err)
+		}
+		if seen[a[i]] {
+			t.Errorf("key %d repeated", i)
+		}
+		seen[a[i]] = true
+	}
+	if FromSeed("other", 1)[0] == a[0] {
+		t.Error("different seeds give the same key")
+	}
+	// a longer run extends, not reshuffles, the shorter one
+	if longer := FromSeed("bench", 8); longer[3] != a[3] {
+		t.Errorf("key 3 changed with n: %s != %s", longer[3], a[3])
+	}
+}
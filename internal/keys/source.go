@@ -0,0 +1,119 @@
+package keys
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FromKeystore decrypts the go-ethereum keystore JSON files of dir, or the single file dir, in
+// name order. passwordFile has one password for all keys, or one line per key file.
+func FromKeystore(dir, passwordFile string) ([]string, error) {
+	files := []string{dir}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			// skip editor backups and hidden files, like geth
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || strings.HasSuffix(entry.Name(), "~") {
+				continue
+			}
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+		sort.Strings(files)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no key files in %s", dir)
+	}
+
+	var passwords []string
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, err
+		}
+		passwords = strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+		for i := range passwords {
+			passwords[i] = strings.TrimRight(passwords[i], "\r")
+		}
+	}
+	if len(passwords) > 1 && len(passwords) != len(files) {
+		return nil, fmt.Errorf("%d passwords for %d key files", len(passwords), len(files))
+	}
+
+	keys := make([]string, len(files))
+	for i, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var password string
+		switch len(passwords) {
+		case 0:
+		case 1:
+			password = passwords[0]
+		default:
+			password = passwords[i]
+		}
+		key, err := keystore.DecryptKey(data, password)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		keys[i] = hex.EncodeToString(crypto.FromECDSA(key.PrivateKey))
+	}
+	return keys, nil
+}
+
+// FromFile one hex private key per line, blank lines and # comments skipped
+func FromFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := check(line); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, n+1, err)
+		}
+		keys = append(keys, line)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys in %s", path)
+	}
+	return keys, nil
+}
+
+// FromEnv hex private keys of the variable, separated by commas or whitespace, nil if not set
+func FromEnv(name string) ([]string, error) {
+	keys := strings.FieldsFunc(os.Getenv(name), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for i, key := range keys {
+		if err := check(key); err != nil {
+			return nil, fmt.Errorf("%s key %d: %w", name, i, err)
+		}
+	}
+	return keys, nil
+}
+
+// check a hex private key, the error never contains the key
+func check(key string) error {
+	if _, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x")); err != nil {
+		return fmt.Errorf("invalid private key")
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package statistics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlockInfo a block observed on chain
+type BlockInfo struct {
+	Number    uint64        `json:"number"`
+	Timestamp time.Time     `json:"timestamp"`
+	Txs       int           `json:"txs"`
+	GasUsed   uint64        `json:"gasUsed"`
+	GasLimit  uint64        `json:"gasLimit"`
+	BaseFee   uint64        `json:"baseFee"` // wei, 0 before london
+	Interval  time.Duration `json:"-"`       // since the previous block, set by Summary
+}
+
+// ChainStats chain level events observed by the tracker during the run
+type ChainStats struct {
+	mutex      sync.Mutex
+	reorgs     uint64
+	maxDepth   uint64
+	depths     map[uint64]uint64 // depth -> reorg count
+	knockedTxs uint64            // txs of ours knocked out of blocks
+	blocks     []BlockInfo       // blocks of the test window, by number
+}
+
+func NewChainStats() *ChainStats {
+	return &ChainStats{depths: make(map[uint64]uint64)}
+}
+
+// AddReorg records a reorg replacing depth blocks, which knocked txs of ours out
+func (cs *ChainStats) AddReorg(depth uint64, txs int) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	cs.reorgs++
+	cs.depths[depth]++
+	if depth > cs.maxDepth {
+		cs.maxDepth = depth
+	}
+	cs.knockedTxs += uint64(txs)
+}
+
+// AddBlock records a block of the test window, blocks are added in order
+func (cs *ChainStats) AddBlock(block BlockInfo) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.blocks = append(cs.blocks, block)
+}
+
+// ChainSummary chain side metrics of the test window, the first block is the base and not counted
+type ChainSummary struct {
+	FirstBlock      uint64            `json:"firstBlock"`
+	LastBlock       uint64            `json:"lastBlock"`
+	Blocks          int               `json:"blocks"`
+	Txs             uint64            `json:"txs"`
+	TPS             float64           `json:"tps"`
+	GasPerSecond    float64           `json:"gasPerSecond"`
+	GasUsed         uint64            `json:"gasUsed"`
+	GasFullness     float64           `json:"gasFullness"` // gasUsed / gasLimit of all blocks, %
+	EmptyBlocks     int               `json:"emptyBlocks"`
+	FullnessMin     float64           `json:"fullnessMin"`
+	FullnessAvg     float64           `json:"fullnessAvg"`
+	FullnessMax     float64           `json:"fullnessMax"`
+	IntervalAvg     float64           `json:"intervalAvg"` // seconds, 1s resolution
+	IntervalP50     float64           `json:"intervalP50"`
+	IntervalP90     float64           `json:"intervalP90"`
+	IntervalP99     float64           `json:"intervalP99"`
+	IntervalMax     float64           `json:"intervalMax"`
+	BaseFeeMin      uint64            `json:"baseFeeMin"`
+	BaseFeeAvg      float64           `json:"baseFeeAvg"`
+	BaseFeeMax      uint64            `json:"baseFeeMax"`
+	Reorgs          uint64            `json:"reorgs"`
+	MaxReorgDepth   uint64            `json:"maxReorgDepth"`
+	ReorgDepths     map[uint64]uint64 `json:"reorgDepths"`
+	ReorgKnockedTxs uint64            `json:"reorgKnockedTxs"`
+}
+
+// Summary chain side metrics, and the blocks after the base block
+func (cs *ChainStats) Summary() (summary ChainSummary, blocks []BlockInfo) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	summary.Reorgs = cs.reorgs
+	summary.MaxReorgDepth = cs.maxDepth
+	summary.ReorgKnockedTxs = cs.knockedTxs
+	summary.ReorgDepths = make(map[uint64]uint64, len(cs.depths))
+	for depth, n := range cs.depths {
+		summary.ReorgDepths[depth] = n
+	}
+	if len(cs.blocks) < 2 {
+		return
+	}
+
+	var intervals durationArray
+	var gasLimit uint64
+	fill := amount{}
+	baseFee := amount{}
+	for i := 1; i < len(cs.blocks); i++ {
+		block := cs.blocks[i]
+		block.Interval = block.Timestamp.Sub(cs.blocks[i-1].Timestamp)
+		blocks = append(blocks, block)
+		intervals = append(intervals, block.Interval)
+		if block.Txs == 0 {
+			summary.EmptyBlocks++
+		}
+		summary.Txs += uint64(block.Txs)
+		summary.GasUsed += block.GasUsed
+		gasLimit += block.GasLimit
+		fill.add(uint64(fullness(block) * 100))
+		baseFee.add(block.BaseFee)
+	}
+
+	first, last := cs.blocks[0], cs.blocks[len(cs.blocks)-1]
+	window := last.Timestamp.Sub(first.Timestamp)
+	summary.FirstBlock = first.Number + 1
+	summary.LastBlock = last.Number
+	summary.Blocks = len(intervals)
+	summary.IntervalAvg = window.Seconds() / float64(len(intervals))
+	if window > 0 {
+		summary.TPS = float64(summary.Txs) / window.Seconds()
+		summary.GasPerSecond = float64(summary.GasUsed) / window.Seconds()
+	}
+	if gasLimit > 0 {
+		summary.GasFullness = float64(summary.GasUsed) * 100 / float64(gasLimit)
+	}
+	summary.FullnessMin, summary.FullnessAvg, summary.FullnessMax = float64(fill.min)/100, fill.avg()/100, float64(fill.max)/100
+	summary.BaseFeeMin, summary.BaseFeeAvg, summary.BaseFeeMax = baseFee.min, baseFee.avg(), baseFee.max
+
+	sort.Sort(intervals)
+	at := func(p float64) float64 { return intervals[int(float64(len(intervals)-1)*p)].Seconds() }
+	summary.IntervalP50, summary.IntervalP90, summary.IntervalP99, summary.IntervalMax = at(0.5), at(0.9), at(0.99), at(1)
+	return
+}
+
+// printBlocks 打印每个区块及链上真实 TPS
+func printBlocks(summary ChainSummary, blocks []BlockInfo) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	fmt.Println("─────────┬───────┬──────────┬──────┬──────────────")
+	fmt.Println("  block  │  txs  │ interval │ gas %│   gasUsed    ")
+	fmt.Println("─────────┼───────┼──────────┼──────┼──────────────")
+	for _, block := range blocks {
+		fmt.Printf("%9d│%7d│%9.0fs│%5.1f%%│%14d\n", block.Number, block.Txs, block.Interval.Seconds(), fullness(block), block.GasUsed)
+	}
+
+	fmt.Printf("链上 chain blocks: %d-%d txs: %d avg interval: %.2fs gas: %.1f%%\n",
+		summary.FirstBlock, summary.LastBlock, summary.Txs, summary.IntervalAvg, summary.GasFullness)
+	if summary.TPS > 0 {
+		fmt.Printf("链上 chain tps: %.2f gas/s: %.0f\n", summary.TPS, summary.GasPerSecond)
+	}
+}
+
+// printProduction 打印出块间隔分布, 空块数量, 区块填充率及 baseFee 变化
+func printProduction(summary ChainSummary, blocks []BlockInfo) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	fmt.Printf("出块间隔 block interval: P50: %.0fs P90: %.0fs P99: %.0fs max: %.0fs\n",
+		summary.IntervalP50, summary.IntervalP90, summary.IntervalP99, summary.IntervalMax)
+	fmt.Printf("空块 empty blocks: %d/%d 填充率 fullness: avg: %.1f%% min: %.1f%% max: %.1f%%\n",
+		summary.EmptyBlocks, summary.Blocks, summary.FullnessAvg, summary.FullnessMin, summary.FullnessMax)
+
+	if summary.BaseFeeMax == 0 {
+		return
+	}
+	// baseFee trajectory, sampled at most 10 points over the run
+	var points []string
+	step := (len(blocks) + 9) / 10
+	for i := 0; i < len(blocks); i += step {
+		points = append(points, fmt.Sprintf("#%d:%d", blocks[i].Number, blocks[i].BaseFee))
+	}
+	if last := blocks[len(blocks)-1]; (len(blocks)-1)%step != 0 {
+		points = append(points, fmt.Sprintf("#%d:%d", last.Number, last.BaseFee))
+	}
+	fmt.Printf("baseFee: avg: %.0f min: %d max: %d wei\n", summary.BaseFeeAvg, summary.BaseFeeMin, summary.BaseFeeMax)
+	fmt.Printf("baseFee trajectory: %s\n", strings.Join(points, " -> "))
+}
+
+func fullness(block BlockInfo) float64 {
+	if block.GasLimit == 0 {
+		return 0
+	}
+	return float64(block.GasUsed) * 100 / float64(block.GasLimit)
+}
+
+// printReorgs 打印 reorg 次数及深度分布, 如 1:3;2:1
+func printReorgs(summary ChainSummary) {
+	var depths []uint64
+	for depth := range summary.ReorgDepths {
+		depths = append(depths, depth)
+	}
+	sort.Slice(depths, func(i, j int) bool { return depths[i] < depths[j] })
+	var dist []string
+	for _, depth := range depths {
+		dist = append(dist, fmt.Sprintf("%d:%d", depth, summary.ReorgDepths[depth]))
+	}
+	fmt.Printf("reorgs: %d max depth: %d knocked txs: %d depths: %s\n",
+		summary.Reorgs, summary.MaxReorgDepth, summary.ReorgKnockedTxs, strings.Join(dist, ";"))
+}
@@ -0,0 +1,415 @@
+package statistics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	collectorShards = 16                     // Record only contends with workers of the same shard
+	drainInterval   = time.Millisecond * 100 // results aggregated in batches
+)
+
+// shard results recorded but not aggregated yet
+type shard struct {
+	mutex    sync.Mutex
+	pending  []*TestResult
+	spare    []*TestResult // drained batch, reused as pending
+	recorded atomic.Uint64
+}
+
+// Collector aggregates results into live and final statistics. Record never waits for the
+// aggregation, which runs in batches on its own goroutine until Close.
+type Collector struct {
+	concurrency uint64
+	chain       *ChainStats
+	opts        Options
+	startTime   time.Time
+
+	shards []shard
+	closed atomic.Bool
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	result *Result
+
+	out  io.Writer  // 实时表格, TUI 模式下写入日志
+	dash *dashboard // nil unless TUI
+
+	// aggregate, owned by the aggregator, Snapshot reads it under mutex
+	mutex          sync.Mutex
+	processingTime time.Duration // processingTime 处理总耗时
+	maxTime        time.Duration // maxTime 至今为止单个请求最大耗时
+	minTime        time.Duration // minTime 至今为止单个请求最小耗时
+	successNum     uint64
+	failureNum     uint64
+	failures       map[FailReason]uint64 // 失败原因及数量
+	errors         map[string]uint64     // RPC 错误类别及数量
+	lastErrors     map[string]uint64     // 上一秒的错误数量
+	chanIds        map[int]bool          // 成功过的 worker
+	workers        map[int]*workerStats  // 每个 worker 的结果
+	ackLatency     *Histogram            // 提交到节点接受
+	seenLatency    *Histogram            // 提交到本地首次看到区块
+	blockLatency   *Histogram            // 提交到区块时间戳
+	confirmLatency *Histogram            // 提交到 N 个确认
+	finalLatency   *Histogram            // 提交到 safe/finalized
+	reorgedNum     uint64                // reorgedNum 曾被 reorg 移出区块
+	gasUsed        amount                // receipt gasUsed, 含 reverted
+	gasPrice       amount                // receipt effectiveGasPrice, 含 reverted
+	window         *windows              // 最近 60 秒, 每秒一个桶
+	samples        []Sample              // 每秒数据
+	lastSent       uint64                // 上一秒的发送数量
+	records        map[string]recorder   // 每笔交易记录, path -> recorder
+}
+
+// Snapshot live statistics at a point of the run
+type Snapshot struct {
+	Elapsed  time.Duration
+	Recorded uint64 // passed to Record, aggregated or not
+	Success  uint64
+	Failure  uint64
+	Failures map[FailReason]uint64
+	Errors   map[string]uint64
+	TPS1s    float64
+	TPS10s   float64
+	TPS60s   float64
+	Latency  LatencySummary // inclusion of all successes so far
+	Workers  []WorkerSummary
+}
+
+// NewCollector starts aggregating, the outputs of opts are opened now and written on Close
+func NewCollector(concurrency uint64, chain *ChainStats, opts Options) *Collector {
+	startTime := time.Now()
+	c := &Collector{
+		concurrency:    concurrency,
+		chain:          chain,
+		opts:           opts,
+		startTime:      startTime,
+		shards:         make([]shard, collectorShards),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+		out:            os.Stdout,
+		minTime:        24 * time.Hour,
+		failures:       make(map[FailReason]uint64),
+		errors:         make(map[string]uint64),
+		lastErrors:     make(map[string]uint64),
+		chanIds:        make(map[int]bool),
+		workers:        make(map[int]*workerStats),
+		ackLatency:     NewHistogram(),
+		seenLatency:    NewHistogram(),
+		blockLatency:   NewHistogram(),
+		confirmLatency: NewHistogram(),
+		finalLatency:   NewHistogram(),
+		window:         newWindows(startTime, 60),
+		records:        make(map[string]recorder),
+	}
+	if chain == nil {
+		c.chain = NewChainStats()
+	}
+	switch {
+	case opts.Quiet:
+		c.out = io.Discard
+	case opts.TUI:
+		c.out = log.Writer()
+		c.dash = newDashboard(os.Stdout)
+	}
+
+	if opts.CSVPath != "" {
+		if w, err := newCSVWriter(opts.CSVPath); err != nil {
+			log.Printf("Failed to create csv %s: %v", opts.CSVPath, err)
+		} else {
+			c.records[opts.CSVPath] = w
+		}
+	}
+	if opts.TracePath != "" {
+		if w, err := newTraceWriter(opts.TracePath); err != nil {
+			log.Printf("Failed to create trace log %s: %v", opts.TracePath, err)
+		} else {
+			c.records[opts.TracePath] = w
+		}
+	}
+	if opts.OTLPPath != "" || opts.OTLPEndpoint != "" {
+		if w, err := newOTLPExporter(opts.OTLPPath, opts.OTLPEndpoint); err != nil {
+			log.Printf("Failed to create otlp traces %s: %v", opts.OTLPPath, err)
+		} else {
+			c.records["otlp"] = w
+		}
+	}
+
+	printHeader(c.out)
+	go c.run()
+	return c
+}
+
+// Record queues a result for aggregation without waiting for it, results after Close are dropped
+func (c *Collector) Record(tr *TestResult) {
+	s := &c.shards[uint(tr.ChanId)%collectorShards]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if c.closed.Load() {
+		return
+	}
+	s.pending = append(s.pending, tr)
+	s.recorded.Add(1)
+}
+
+// Close stops the aggregation after the recorded results, prints the final statistics and
+// writes the outputs. The result document is returned, also on repeated calls.
+func (c *Collector) Close() *Result {
+	c.once.Do(func() {
+		c.closed.Store(true)
+		close(c.stop)
+		<-c.done
+		c.result = c.report(time.Now())
+	})
+	return c.result
+}
+
+// Snapshot live statistics, safe to call at any time
+func (c *Collector) Snapshot() Snapshot {
+	now := time.Now()
+	snap := Snapshot{Elapsed: now.Sub(c.startTime)}
+	for i := range c.shards {
+		snap.Recorded += c.shards[i].recorded.Load()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	snap.Success, snap.Failure = c.successNum, c.failureNum
+	snap.Failures = make(map[FailReason]uint64, len(c.failures))
+	for reason, n := range c.failures {
+		snap.Failures[reason] = n
+	}
+	snap.Errors = make(map[string]uint64, len(c.errors))
+	for category, n := range c.errors {
+		snap.Errors[category] = n
+	}
+	snap.TPS1s, snap.TPS10s, snap.TPS60s = c.window.stat(now, 1).tps, c.window.stat(now, 10).tps, c.window.stat(now, 60).tps
+	snap.Latency = latencySummary(c.seenLatency)
+	snap.Workers = summarizeWorkers(c.workers, c.opts.Metrics, c.startTime, now)
+	return snap
+}
+
+// run the aggregator, drains the shards in batches and prints live statistics every second
+func (c *Collector) run() {
+	defer close(c.done)
+	drain := time.NewTicker(drainInterval)
+	defer drain.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-drain.C:
+			c.drain()
+		case now := <-ticker.C:
+			c.drain()
+			c.tick(now)
+		case <-c.stop:
+			c.drain()
+			return
+		}
+	}
+}
+
+// drain aggregates the pending results of all shards
+func (c *Collector) drain() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mutex.Lock()
+		batch := s.pending
+		s.pending, s.spare = s.spare[:0], nil
+		s.mutex.Unlock()
+		if len(batch) == 0 {
+			s.spare = batch
+			continue
+		}
+
+		now := time.Now()
+		c.mutex.Lock()
+		for _, tr := range batch {
+			c.add(now, tr)
+		}
+		c.mutex.Unlock()
+		clear(batch)
+		s.spare = batch
+	}
+}
+
+// add must hold mutex
+func (c *Collector) add(now time.Time, tr *TestResult) {
+	for path, w := range c.records {
+		if err := w.write(tr); err != nil {
+			log.Printf("Failed to write %s: %v", path, err)
+		}
+	}
+	c.window.add(now, tr)
+	c.opts.Metrics.record(tr)
+	ws, ok := c.workers[tr.ChanId]
+	if !ok {
+		ws = &workerStats{latency: NewHistogram()}
+		c.workers[tr.ChanId] = ws
+	}
+	ws.add(now, tr)
+
+	// total process time
+	c.processingTime += tr.Cost
+	if tr.Success {
+		c.successNum++
+		if c.maxTime <= tr.Cost {
+			c.maxTime = tr.Cost
+		}
+		if c.minTime > tr.Cost {
+			c.minTime = tr.Cost
+		}
+		c.chanIds[tr.ChanId] = true
+
+		// success cost time, for percentiles
+		c.ackLatency.Record(tr.AckCost())
+		c.seenLatency.Record(tr.Cost)
+		c.blockLatency.Record(tr.BlockCost())
+		if tr.ConfirmCost() > 0 {
+			c.confirmLatency.Record(tr.ConfirmCost())
+		}
+		if tr.FinalizeCost() > 0 {
+			c.finalLatency.Record(tr.FinalizeCost())
+		}
+	} else {
+		c.failureNum++
+		c.failures[tr.Failure]++
+		if tr.Failure == FailRejected {
+			c.errors[ClassifyError(tr.Error)]++
+		}
+	}
+	if tr.Reorged > 0 {
+		c.reorgedNum++
+	}
+	if tr.Success || tr.Failure == FailReverted {
+		c.gasUsed.add(tr.GasUsed)
+		c.gasPrice.add(tr.GasPrice)
+	}
+}
+
+// tick samples the last second, flushes the records and prints the live statistics
+func (c *Collector) tick(now time.Time) {
+	c.mutex.Lock()
+	elapsed := now.Sub(c.startTime)
+	codes := printMap(c.errors, c.lastErrors)
+	live := printWindows(c.window, now)
+	s := sample(c.window, now, elapsed, c.successNum, c.failureNum)
+	sent, pending := c.opts.Metrics.senders()
+	s.Offered, s.Mempool, c.lastSent = float64(sent-c.lastSent), pending, sent
+	s.Backlog = c.opts.Metrics.backlog()
+	c.samples = append(c.samples, s)
+	for path, w := range c.records {
+		if err := w.flush(); err != nil {
+			log.Printf("Failed to flush %s: %v", path, err)
+		}
+	}
+	summaries := summarizeWorkers(c.workers, c.opts.Metrics, c.startTime, now)
+	row := calculateData(c.concurrency, c.processingTime, elapsed, c.maxTime, c.minTime, c.successNum, c.failureNum, uint64(len(c.chanIds)), live, codes, outliers(summaries))
+	var samples []Sample
+	if c.dash != nil {
+		samples = append(samples, c.samples[max(0, len(c.samples)-sparkWidth):]...)
+	}
+	c.mutex.Unlock()
+
+	fmt.Fprint(c.out, row)
+	if c.dash != nil {
+		c.dash.render(elapsed, samples, live, c.opts.Metrics, summaries)
+	}
+}
+
+// report prints the final statistics and writes the outputs, after the aggregator stopped
+func (c *Collector) report(endTime time.Time) *Result {
+	// Snapshot may still be called
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	requestCostTime := endTime.Sub(c.startTime)
+	workerSummaries := summarizeWorkers(c.workers, c.opts.Metrics, c.startTime, endTime)
+	fmt.Fprint(c.out, calculateData(c.concurrency, c.processingTime, requestCostTime, c.maxTime, c.minTime, c.successNum, c.failureNum,
+		uint64(len(c.chanIds)), printWindows(c.window, endTime), printMap(c.errors, c.lastErrors), outliers(workerSummaries)))
+
+	chainSummary, blocks := c.chain.Summary()
+	if !c.opts.Quiet {
+		fmt.Printf("\n\n")
+		fmt.Println("*************************  结果 stat  ****************************")
+		fmt.Println("处理协程数量:", c.concurrency)
+		fmt.Printf("请求总数: %d 总请求时间: %.3f秒 successNum: %d failureNum: %d\n",
+			c.successNum+c.failureNum, requestCostTime.Seconds(), c.successNum, c.failureNum)
+		printFailures(c.failures)
+		printErrors(c.errors)
+		printWorkers(workerSummaries)
+		printHistogram("提交确认 submit->ack:      ", c.ackLatency, ms)
+		printHistogram("首次出块 submit->seen:     ", c.seenLatency, ms)
+		printHistogram("区块时间 submit->block:    ", c.blockLatency, sec)
+		printHistogram("确认 submit->confirmed:    ", c.confirmLatency, ms)
+		printHistogram("最终 submit->finalized:    ", c.finalLatency, ms)
+		if c.reorgedNum > 0 {
+			fmt.Printf("reorged txs: %d\n", c.reorgedNum)
+		}
+		printReorgs(chainSummary)
+		printBlocks(chainSummary, blocks)
+		printProduction(chainSummary, blocks)
+		printGas(c.gasUsed, c.gasPrice)
+		fmt.Println("*************************  结果 end   ****************************")
+		fmt.Printf("\n\n")
+	}
+
+	result := &Result{
+		Config:      c.opts.Config,
+		Environment: environment(),
+		StartTime:   c.startTime,
+		EndTime:     endTime,
+		Summary: Summary{
+			Concurrency: c.concurrency,
+			Workers:     uint64(len(c.chanIds)),
+			Total:       c.successNum + c.failureNum,
+			Success:     c.successNum,
+			Failure:     c.failureNum,
+			Reorged:     c.reorgedNum,
+			Duration:    requestCostTime.Seconds(),
+			TPS:         float64(c.successNum) / requestCostTime.Seconds(),
+			GasUsed:     c.gasUsed.total,
+			GasPriceAvg: c.gasPrice.avg(),
+		},
+		Latency: map[string]LatencySummary{
+			"ack":       latencySummary(c.ackLatency),
+			"inclusion": latencySummary(c.seenLatency),
+			"block":     latencySummary(c.blockLatency),
+			"confirmed": latencySummary(c.confirmLatency),
+			"finalized": latencySummary(c.finalLatency),
+		},
+		Workers:    workerSummaries,
+		Failures:   c.failures,
+		Errors:     c.errors,
+		Chain:      chainSummary,
+		Blocks:     blocks,
+		TimeSeries: c.samples,
+	}
+	if result.Summary.Total > 0 {
+		result.Summary.ErrorRate = float64(c.failureNum) / float64(result.Summary.Total)
+	}
+
+	for path, w := range c.records {
+		if err := w.close(); err != nil {
+			log.Printf("Failed to close %s: %v", path, err)
+		}
+	}
+	if c.opts.JSONPath != "" {
+		if err := result.WriteJSON(c.opts.JSONPath); err != nil {
+			log.Printf("Failed to write result %s: %v", c.opts.JSONPath, err)
+		}
+	}
+	if c.opts.HTMLPath != "" {
+		if err := result.WriteHTML(c.opts.HTMLPath); err != nil {
+			log.Printf("Failed to write report %s: %v", c.opts.HTMLPath, err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package statistics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCollectorConcurrent(t *testing.T) {
+	const workers, perWorker = 8, 2000
+	c := NewCollector(workers, NewChainStats(), Options{Quiet: true})
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				now := time.Now()
+				tr := &TestResult{ChanId: w, Nonce: uint64(i), TxHash: fmt.Sprintf("0x%d-%d", w, i), SignTime: now, ReqTime: now, AckTime: now}
+				if i%4 == 0 {
+					tr.Failure, tr.Error = FailRejected, "nonce too low"
+				} else {
+					tr.Success, tr.SeenTime, tr.BlockTime, tr.Cost = true, now, now, time.Millisecond
+				}
+				c.Record(tr)
+			}
+		}(w)
+	}
+	// snapshots while recording
+	stop := make(chan struct{})
+	snapped := make(chan struct{})
+	go func() {
+		defer close(snapped)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			snap := c.Snapshot()
+			if snap.Success+snap.Failure > snap.Recorded {
+				t.Errorf("snapshot: %d aggregated of %d recorded", snap.Success+snap.Failure, snap.Recorded)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-snapped
+
+	result := c.Close()
+	total := uint64(workers * perWorker)
+	if result.Summary.Total != total || result.Summary.Failure != total/4 || result.Summary.Success != total-total/4 {
+		t.Errorf("summary %+v, want %d total, %d failed", result.Summary, total, total/4)
+	}
+	if result.Failures[FailRejected] != total/4 || result.Errors["nonce too low"] != total/4 {
+		t.Errorf("failures %v errors %v, want %d rejected as nonce too low", result.Failures, result.Errors, total/4)
+	}
+
+	// closed, later results are dropped and Close returns the same result
+	c.Record(&TestResult{ChanId: 0, Success: true})
+	if snap := c.Snapshot(); snap.Recorded != total {
+		t.Errorf("recorded %d after close, want %d", snap.Recorded, total)
+	}
+	if c.Close() != result {
+		t.Error("repeated Close: different result")
+	}
+}
@@ -0,0 +1,280 @@
+package statistics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// windowSeconds of TPS10s, P50 and P99 in the time series
+const windowSeconds = 10
+
+// Thresholds regressions of a run against the baseline, only significant changes count
+type Thresholds struct {
+	MaxTPSDrop           float64 // %
+	MaxLatencyIncrease   float64 // % of inclusion latency percentiles
+	MaxErrorRateIncrease float64 // percentage points
+	Alpha                float64 // significance level
+}
+
+// Delta one compared metric
+type Delta struct {
+	Metric    string
+	Base      float64
+	Current   float64
+	Change    float64 // %, or percentage points for rates
+	PValue    float64 // NaN if not tested
+	Exceeded  bool    // change beyond the threshold
+	Regressed bool    // exceeded and significant
+}
+
+// Untested exceeded without a test of significance, e.g. too few windows
+func (d Delta) Untested() bool {
+	return d.Exceeded && math.IsNaN(d.PValue)
+}
+
+// ReadResult reads a result document written by WriteJSON
+func ReadResult(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Compare current against base. TPS and latency p50/p99 are tested with Welch's t-test on
+// non-overlapping 10s windows of the steady state, error rate with a two proportion z-test.
+// Other metrics exceeding their threshold are reported as not tested.
+func Compare(base, current *Result, th Thresholds) []Delta {
+	var deltas []Delta
+
+	tps := Delta{Metric: "tps", Base: base.Summary.TPS, Current: current.Summary.TPS}
+	tps.Change = change(tps.Base, tps.Current)
+	tps.PValue = welch(series(base, func(s Sample) float64 { return s.TPS10s }), series(current, func(s Sample) float64 { return s.TPS10s }))
+	tps.Exceeded = -tps.Change > th.MaxTPSDrop
+	tps.Regressed = tps.Exceeded && significant(tps.PValue, th.Alpha)
+	deltas = append(deltas, tps)
+
+	chainTPS := Delta{Metric: "chain tps", Base: base.Chain.TPS, Current: current.Chain.TPS, PValue: math.NaN()}
+	chainTPS.Change = change(chainTPS.Base, chainTPS.Current)
+	chainTPS.Exceeded = -chainTPS.Change > th.MaxTPSDrop
+	deltas = append(deltas, chainTPS)
+
+	baseLatency, curLatency := base.Latency["inclusion"], current.Latency["inclusion"]
+	for _, p := range percentiles {
+		key := fmt.Sprintf("p%v", p)
+		d := Delta{Metric: "inclusion " + key, Base: baseLatency.Percentiles[key], Current: curLatency.Percentiles[key], PValue: math.NaN()}
+		d.Change = change(d.Base, d.Current)
+		// windowed percentiles in the time series
+		switch p {
+		case 50:
+			d.PValue = welch(series(base, func(s Sample) float64 { return s.P50 }), series(current, func(s Sample) float64 { return s.P50 }))
+		case 99:
+			d.PValue = welch(series(base, func(s Sample) float64 { return s.P99 }), series(current, func(s Sample) float64 { return s.P99 }))
+		}
+		d.Exceeded = d.Change > th.MaxLatencyIncrease
+		d.Regressed = d.Exceeded && significant(d.PValue, th.Alpha)
+		deltas = append(deltas, d)
+	}
+
+	errRate := Delta{Metric: "error rate %", Base: base.Summary.ErrorRate * 100, Current: current.Summary.ErrorRate * 100}
+	errRate.Change = errRate.Current - errRate.Base
+	errRate.PValue = proportions(base.Summary.Failure, base.Summary.Total, current.Summary.Failure, current.Summary.Total)
+	errRate.Exceeded = errRate.Change > th.MaxErrorRateIncrease
+	errRate.Regressed = errRate.Exceeded && significant(errRate.PValue, th.Alpha)
+	deltas = append(deltas, errRate)
+
+	return deltas
+}
+
+// PrintDeltas prints the comparison of one run against the baseline
+func PrintDeltas(name string, deltas []Delta) {
+	fmt.Printf("\n%s\n", name)
+	fmt.Println("──────────────────┬────────────┬────────────┬──────────┬─────────┬──────────")
+	fmt.Println("      metric      │    base    │  current   │  change  │ p-value │ verdict  ")
+	fmt.Println("──────────────────┼────────────┼────────────┼──────────┼─────────┼──────────")
+	for _, d := range deltas {
+		pValue := "      -"
+		if !math.IsNaN(d.PValue) {
+			pValue = fmt.Sprintf("%7.4f", d.PValue)
+		}
+		verdict := "ok"
+		switch {
+		case d.Regressed:
+			verdict = "REGRESSED"
+		case d.Untested():
+			verdict = "not tested"
+		}
+		unit := "%"
+		if d.Metric == "error rate %" {
+			unit = "pp"
+		}
+		fmt.Printf("%-18s│%12.2f│%12.2f│%+8.2f%-2s│ %s │ %s\n", d.Metric, d.Base, d.Current, d.Change, unit, pValue, verdict)
+	}
+}
+
+func change(base, current float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return (current - base) / base * 100
+}
+
+// significant false if not tested
+func significant(pValue, alpha float64) bool {
+	return pValue < alpha
+}
+
+// series values of non-overlapping windows of the steady state, the per second samples
+// of the rolling windows are strongly correlated
+func series(r *Result, value func(Sample) float64) []float64 {
+	samples := steady(r.TimeSeries)
+	var values []float64
+	for i := windowSeconds - 1; i < len(samples); i += windowSeconds {
+		values = append(values, value(samples[i]))
+	}
+	return values
+}
+
+// steady samples while the senders were sending, without the first window of ramp-up
+// and the drain after. Without sender metrics a window is skipped at both ends.
+func steady(samples []Sample) []Sample {
+	first, last := -1, -1
+	for i, s := range samples {
+		if s.Offered > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		first, last = 0, len(samples)-1-windowSeconds
+	}
+	first += windowSeconds
+	if first > last {
+		return nil
+	}
+	return samples[first : last+1]
+}
+
+// welch two sided p-value of Welch's t-test, NaN if not enough samples
+func welch(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return math.NaN()
+	}
+	meanA, varA := meanVar(a)
+	meanB, varB := meanVar(b)
+	sa, sb := varA/float64(len(a)), varB/float64(len(b))
+	if sa+sb == 0 {
+		if meanA == meanB {
+			return 1
+		}
+		return 0
+	}
+	t := (meanA - meanB) / math.Sqrt(sa+sb)
+	df := (sa + sb) * (sa + sb) / (sa*sa/float64(len(a)-1) + sb*sb/float64(len(b)-1))
+	// P(|T| > |t|) = I_{df/(df+t^2)}(df/2, 1/2)
+	return incompleteBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// proportions two sided p-value of the two proportion z-test, NaN if empty
+func proportions(x1, n1, x2, n2 uint64) float64 {
+	if n1 == 0 || n2 == 0 {
+		return math.NaN()
+	}
+	p1, p2 := float64(x1)/float64(n1), float64(x2)/float64(n2)
+	p := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(p * (1 - p) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		if p1 == p2 {
+			return 1
+		}
+		return 0
+	}
+	z := (p2 - p1) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+func meanVar(values []float64) (mean, variance float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+	return
+}
+
+// incompleteBeta regularized incomplete beta function I_x(a, b)
+func incompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges fast below the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaFraction(a, b, x) / a
+	}
+	return 1 - front*betaFraction(b, a, 1-x)/b
+}
+
+// betaFraction continued fraction of the incomplete beta, modified Lentz's method
+func betaFraction(a, b, x float64) float64 {
+	const (
+		maxIter = 200
+		epsilon = 1e-12
+		tiny    = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	f := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		// even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		f *= d * c
+		// odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		f *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return f
+}
@@ -0,0 +1,108 @@
+package statistics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sparkWidth    = 60 // seconds shown by the sparklines
+	maxWorkerRows = 20 // workers shown, outliers first, the rest summarized
+)
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard redraws the live statistics in place, instead of the scrolling table
+type dashboard struct {
+	out io.Writer
+}
+
+func newDashboard(out io.Writer) *dashboard {
+	return &dashboard{out: out}
+}
+
+// render one frame from the time series, senders status and recent failures
+func (d *dashboard) render(elapsed time.Duration, samples []Sample, windowLine string, metrics *Metrics, summaries []WorkerSummary) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J") // cursor home, clear screen
+
+	var last Sample
+	if len(samples) > 0 {
+		last = samples[len(samples)-1]
+	}
+	if len(samples) > sparkWidth {
+		samples = samples[len(samples)-sparkWidth:]
+	}
+	series := func(value func(Sample) float64) []float64 {
+		values := make([]float64, len(samples))
+		for i, s := range samples {
+			values[i] = value(s)
+		}
+		return values
+	}
+
+	fmt.Fprintf(&b, "evm-bench  elapsed %s  success %d  failed %d\n\n",
+		elapsed.Truncate(time.Second), last.Success, last.Failure)
+	fmt.Fprintf(&b, " tps 1s/10s/60s, p50/p99 10s, p99 60s: %s\n\n", strings.ReplaceAll(windowLine, "│", " "))
+	fmt.Fprintf(&b, " confirmed tx/s %9.1f  %s\n", last.TPS1s, sparkline(series(func(s Sample) float64 { return s.TPS1s })))
+	fmt.Fprintf(&b, " offered tx/s   %9.1f  %s\n", last.Offered, sparkline(series(func(s Sample) float64 { return s.Offered })))
+	fmt.Fprintf(&b, " p50 10s ms     %9.1f  %s\n", last.P50, sparkline(series(func(s Sample) float64 { return s.P50 })))
+	fmt.Fprintf(&b, " p99 10s ms     %9.1f  %s\n", last.P99, sparkline(series(func(s Sample) float64 { return s.P99 })))
+	fmt.Fprintf(&b, " mempool        %9d  %s\n", last.Mempool, sparkline(series(func(s Sample) float64 { return float64(s.Mempool) })))
+	fmt.Fprintf(&b, " tracker backlog %8d  %s\n\n", last.Backlog, sparkline(series(func(s Sample) float64 { return float64(s.Backlog) })))
+
+	workers, recent := metrics.workerStatuses()
+	flags := make(map[int]string)
+	for _, s := range summaries {
+		flags[s.Worker] = s.Outlier
+	}
+	// outliers first
+	sort.SliceStable(workers, func(i, j int) bool { return flags[workers[i].id] != "" && flags[workers[j].id] == "" })
+	fmt.Fprintf(&b, " worker │    nonce │     sent │ in-flight │  errors │ outlier\n")
+	fmt.Fprintf(&b, "────────┼──────────┼──────────┼───────────┼─────────┼─────────\n")
+	for i, ws := range workers {
+		if i == maxWorkerRows {
+			fmt.Fprintf(&b, " ... %d more workers\n", len(workers)-maxWorkerRows)
+			break
+		}
+		fmt.Fprintf(&b, " %6d │ %8d │ %8d │ %9d │ %7d │ %s\n", ws.id, ws.nonce, ws.sent, int64(ws.sent)-int64(ws.done), ws.failed, flags[ws.id])
+	}
+
+	b.WriteString("\n recent errors:\n")
+	if len(recent) == 0 {
+		b.WriteString("  -\n")
+	}
+	for i := len(recent) - 1; i >= 0; i-- {
+		e := recent[i]
+		fmt.Fprintf(&b, "  %s w%d %s\n", e.time.Format("15:04:05"), e.worker, truncate(e.message, 100))
+	}
+	_, _ = io.WriteString(d.out, b.String())
+}
+
+// sparkline scaled to the max of values
+func sparkline(values []float64) string {
+	var max float64
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	runes := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if max > 0 && v > 0 {
+			level = int(v / max * float64(len(sparkRunes)-1))
+		}
+		runes[i] = sparkRunes[level]
+	}
+	return string(runes)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,33 @@
+package statistics
+
+import "strings"
+
+// error categories of eth_sendRawTransaction responses
+var errorCategories = []struct {
+	category string
+	patterns []string
+}{
+	{"nonce too low", []string{"nonce too low", "invalid nonce", "incorrect nonce"}},
+	{"nonce too high", []string{"nonce too high", "nonce gap"}},
+	{"already known", []string{"already known", "already in mempool", "tx already exists"}},
+	{"replacement underpriced", []string{"replacement transaction underpriced", "replacement underpriced"}},
+	{"underpriced", []string{"underpriced", "fee too low", "insufficient fee", "gas price too low"}},
+	{"txpool full", []string{"txpool is full", "mempool is full", "tx pool is full"}},
+	{"insufficient funds", []string{"insufficient funds", "insufficient balance"}},
+	{"gas limit exceeded", []string{"exceeds block gas limit", "gas limit reached", "out of gas", "intrinsic gas too low", "gas limit"}},
+	{"rate limited", []string{"rate limit", "too many requests", "limit exceeded"}},
+	{"timeout", []string{"timeout", "deadline exceeded"}},
+}
+
+// ClassifyError normalizes an RPC error message into a category, unknown messages are "other"
+func ClassifyError(msg string) string {
+	msg = strings.ToLower(msg)
+	for _, c := range errorCategories {
+		for _, pattern := range c.patterns {
+			if strings.Contains(msg, pattern) {
+				return c.category
+			}
+		}
+	}
+	return "other"
+}
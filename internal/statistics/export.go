@@ -0,0 +1,200 @@
+package statistics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Options outputs of a run, empty paths disable
+type Options struct {
+	JSONPath     string      // result document
+	CSVPath      string      // per tx records
+	TracePath    string      // per tx trace log, JSON lines
+	HTMLPath     string      // self-contained HTML report
+	OTLPPath     string      // per tx OTLP/JSON traces, one export request per line
+	OTLPEndpoint string      // OTLP/HTTP collector traces url, e.g. http://127.0.0.1:4318/v1/traces
+	Config       interface{} // run config recorded into the result document
+	Metrics      *Metrics    // prometheus metrics fed with every result, nil disable
+	TUI          bool        // redraw a dashboard in place, the live table goes to the log
+	Quiet        bool        // print nothing, for use as a library
+}
+
+// Result the result document of a run
+type Result struct {
+	Config      interface{}               `json:"config"`
+	Environment Environment               `json:"environment"`
+	StartTime   time.Time                 `json:"startTime"`
+	EndTime     time.Time                 `json:"endTime"`
+	Summary     Summary                   `json:"summary"`
+	Latency     map[string]LatencySummary `json:"latency"` // measure -> latency in ms
+	Workers     []WorkerSummary           `json:"workers"`
+	Failures    map[FailReason]uint64     `json:"failures"`
+	Errors      map[string]uint64         `json:"errors"` // RPC error category -> count
+	Chain       ChainSummary              `json:"chain"`
+	Blocks      []BlockInfo               `json:"blocks"`
+	TimeSeries  []Sample                  `json:"timeSeries"`
+}
+
+type Environment struct {
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	Hostname  string `json:"hostname"`
+}
+
+type Summary struct {
+	Concurrency uint64  `json:"concurrency"`
+	Workers     uint64  `json:"workers"`
+	Total       uint64  `json:"total"`
+	Success     uint64  `json:"success"`
+	Failure     uint64  `json:"failure"`
+	Reorged     uint64  `json:"reorged"`
+	Duration    float64 `json:"duration"` // seconds
+	TPS         float64 `json:"tps"`      // success per second
+	ErrorRate   float64 `json:"errorRate"`
+	GasUsed     uint64  `json:"gasUsed"`
+	GasPriceAvg float64 `json:"gasPriceAvg"`
+}
+
+// LatencySummary of one latency measure, in ms
+type LatencySummary struct {
+	Count       uint64             `json:"count"`
+	Mean        float64            `json:"mean"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles"` // "p99.9" -> ms
+}
+
+// Sample live statistics of one second
+type Sample struct {
+	Elapsed float64 `json:"elapsed"` // seconds since start
+	Success uint64  `json:"success"`
+	Failure uint64  `json:"failure"`
+	Offered float64 `json:"offered"` // sent per second
+	Mempool int64   `json:"mempool"` // pending txs reported by senders
+	Backlog int64   `json:"backlog"` // txs queued for and held by the tracker
+	TPS1s   float64 `json:"tps1s"`
+	TPS10s  float64 `json:"tps10s"`
+	P50     float64 `json:"p50"` // last 10s, ms
+	P99     float64 `json:"p99"` // last 10s, ms
+}
+
+func environment() Environment {
+	hostname, _ := os.Hostname()
+	return Environment{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		Hostname:  hostname,
+	}
+}
+
+func latencySummary(h *Histogram) LatencySummary {
+	ls := LatencySummary{
+		Count:       h.Count(),
+		Mean:        toMs(h.Mean()),
+		Min:         toMs(h.Min()),
+		Max:         toMs(h.Max()),
+		Percentiles: make(map[string]float64, len(percentiles)),
+	}
+	for _, p := range percentiles {
+		ls.Percentiles[fmt.Sprintf("p%v", p)] = toMs(h.Percentile(p))
+	}
+	return ls
+}
+
+func toMs(d time.Duration) float64 {
+	return d.Seconds() * 1000
+}
+
+// WriteJSON writes the result document
+func (r *Result) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+var csvHeader = []string{
+	"worker", "nonce", "hash", "submit", "ack", "block", "block_hash", "block_time", "seen", "confirmed", "finalized",
+	"cost_ms", "success", "failure", "error", "gas_used", "gas_price", "logs", "reorged",
+}
+
+// recorder streams per tx records to a file
+type recorder interface {
+	write(tr *TestResult) error
+	flush() error
+	close() error
+}
+
+// csvWriter streams per tx records
+type csvWriter struct {
+	file *os.File
+	w    *csv.Writer
+}
+
+func newCSVWriter(path string) (*csvWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := csv.NewWriter(file)
+	if err := w.Write(csvHeader); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &csvWriter{file: file, w: w}, nil
+}
+
+func (cw *csvWriter) write(tr *TestResult) error {
+	return cw.w.Write([]string{
+		strconv.Itoa(tr.ChanId),
+		strconv.FormatUint(tr.Nonce, 10),
+		tr.TxHash,
+		formatTime(tr.ReqTime),
+		formatTime(tr.AckTime),
+		strconv.FormatUint(tr.BlockNum, 10),
+		tr.BlockHash,
+		formatTime(tr.BlockTime),
+		formatTime(tr.SeenTime),
+		formatTime(tr.ConfirmedTime),
+		formatTime(tr.FinalizedTime),
+		strconv.FormatFloat(toMs(tr.Cost), 'f', 3, 64),
+		strconv.FormatBool(tr.Success),
+		string(tr.Failure),
+		tr.Error,
+		strconv.FormatUint(tr.GasUsed, 10),
+		strconv.FormatUint(tr.GasPrice, 10),
+		strconv.Itoa(tr.Logs),
+		strconv.Itoa(tr.Reorged),
+	})
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		_ = cw.file.Close()
+		return err
+	}
+	return cw.file.Close()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
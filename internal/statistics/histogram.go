@@ -0,0 +1,139 @@
+package statistics
+
+import (
+	"math/bits"
+	"time"
+)
+
+// subBits 128 sub buckets per power of two, relative error < 1%
+const (
+	subBits  = 7
+	subCount = 1 << subBits
+)
+
+// Histogram HDR style log-linear histogram of durations in microseconds.
+// Memory is bounded by the max value, not the count, and histograms are mergeable.
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a duration, negative durations are counted in the lowest bucket
+func (h *Histogram) Record(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if h.count == 0 || d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+
+	var v uint64
+	if d > 0 {
+		v = uint64(d / time.Microsecond)
+	}
+	i := bucketOf(v)
+	if i >= len(h.counts) {
+		counts := make([]uint64, i+subCount)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+}
+
+// Merge adds all values of o into h
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if h.count == 0 || o.max > h.max {
+		h.max = o.max
+	}
+	h.count += o.count
+	h.sum += o.sum
+	if len(o.counts) > len(h.counts) {
+		counts := make([]uint64, len(o.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, n := range o.counts {
+		h.counts[i] += n
+	}
+}
+
+// Percentile value at p in [0, 100], upper bound of its bucket and capped by max
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	if p >= 100 {
+		return h.max
+	}
+	rank := uint64(p / 100 * float64(h.count))
+	if rank >= h.count {
+		rank = h.count - 1
+	}
+	var seen uint64
+	for i, n := range h.counts {
+		seen += n
+		if seen > rank {
+			d := time.Duration(bucketUpper(i)) * time.Microsecond
+			if d > h.max {
+				return h.max
+			}
+			if d < h.min {
+				return h.min
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// bucketOf values below 2*subCount have own buckets, above share subCount buckets per power of two
+func bucketOf(v uint64) int {
+	if v < 2*subCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBits - 1
+	return subCount*shift + int(v>>shift)
+}
+
+func bucketUpper(i int) uint64 {
+	if i < 2*subCount {
+		return uint64(i)
+	}
+	shift := i/subCount - 1
+	m := uint64(i - subCount*shift)
+	return (m+1)<<shift - 1
+}
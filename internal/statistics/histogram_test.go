@@ -0,0 +1,114 @@
+package statistics
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBuckets(t *testing.T) {
+	for k := 0; k < 48; k++ {
+		base := uint64(1) << k
+		for _, v := range []uint64{base - 1, base, base + 1, base + base/3, 2*base - 1} {
+			upper := bucketUpper(bucketOf(v))
+			if upper < v {
+				t.Fatalf("value %d: bucket upper %d below it", v, upper)
+			}
+			if float64(upper-v) > float64(v)/100 {
+				t.Fatalf("value %d: bucket upper %d, error over 1%%", v, upper)
+			}
+		}
+	}
+	// buckets are ordered, and the upper bound of a bucket is in it
+	last := 0
+	for v := uint64(0); v < 1<<20; v += 7 {
+		i := bucketOf(v)
+		if i < last {
+			t.Fatalf("value %d: bucket %d before %d", v, i, last)
+		}
+		last = i
+	}
+	for i := 0; i <= bucketOf(^uint64(0)); i++ {
+		if got := bucketOf(bucketUpper(i)); got != i {
+			t.Fatalf("bucket %d: upper %d in bucket %d", i, bucketUpper(i), got)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	h := NewHistogram()
+	if h.Percentile(50) != 0 {
+		t.Error("empty histogram: non zero percentile")
+	}
+
+	// a single value is returned as is, clamped by min and max
+	d := 1234567 * time.Microsecond
+	h.Record(d)
+	for _, p := range []float64{0, 50, 99.9, 100, 120} {
+		if got := h.Percentile(p); got != d {
+			t.Errorf("p%v of a single value: got %s, want %s", p, got, d)
+		}
+	}
+
+	h = NewHistogram()
+	for v := 1; v <= 100000; v++ {
+		h.Record(time.Duration(v) * time.Millisecond)
+	}
+	for _, p := range []float64{1, 50, 90, 99, 99.9} {
+		want := time.Duration(p/100*100000) * time.Millisecond
+		got := h.Percentile(p)
+		if got < want || float64(got-want) > float64(want)/100 {
+			t.Errorf("p%v: got %s, want %s within 1%%", p, got, want)
+		}
+	}
+	if h.Percentile(100) != h.Max() || h.Max() != 100*time.Second {
+		t.Errorf("p100: got %s, max %s", h.Percentile(100), h.Max())
+	}
+	if h.Min() != time.Millisecond {
+		t.Errorf("min: got %s", h.Min())
+	}
+
+	// negative durations go to the lowest bucket, min stays exact
+	h.Record(-time.Second)
+	if h.Min() != -time.Second || h.Percentile(0) != 0 {
+		t.Errorf("negative: min %s, p0 %s", h.Min(), h.Percentile(0))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	all, a, b := NewHistogram(), NewHistogram(), NewHistogram()
+	for i := 0; i < 20000; i++ {
+		// a with short, b with long durations, so that the bucket slices differ in length
+		d := time.Duration(rnd.ExpFloat64() * float64(50*time.Millisecond))
+		target := a
+		if i%2 == 1 {
+			d *= 1000
+			target = b
+		}
+		all.Record(d)
+		target.Record(d)
+	}
+
+	merged := NewHistogram()
+	merged.Merge(nil)
+	merged.Merge(NewHistogram())
+	merged.Merge(a)
+	merged.Merge(b)
+	if merged.Count() != all.Count() || merged.Min() != all.Min() || merged.Max() != all.Max() || merged.Mean() != all.Mean() {
+		t.Fatalf("merged count %d min %s max %s mean %s, want %d %s %s %s",
+			merged.Count(), merged.Min(), merged.Max(), merged.Mean(), all.Count(), all.Min(), all.Max(), all.Mean())
+	}
+	for _, p := range []float64{0, 10, 50, 90, 95, 99, 99.9, 100} {
+		if got, want := merged.Percentile(p), all.Percentile(p); got != want {
+			t.Errorf("p%v: merged %s, want %s", p, got, want)
+		}
+	}
+
+	// merging the larger into the smaller histogram grows it
+	b.Merge(a)
+	a.Merge(b)
+	if a.Count() != 3*all.Count()/2 {
+		t.Errorf("count after merging back: %d", a.Count())
+	}
+}
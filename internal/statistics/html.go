@@ -0,0 +1,266 @@
+package statistics
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	chartWidth  = 860
+	chartHeight = 260
+	chartLeft   = 60 // room for y axis labels
+	chartBottom = 30 // room for x axis labels
+)
+
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// chartSeries one line of a chart
+type chartSeries struct {
+	name string
+	x, y []float64
+}
+
+// bar one bar of a bar chart
+type bar struct {
+	label string
+	value uint64
+}
+
+// WriteHTML writes a self-contained report with SVG charts, no external resources
+func (r *Result) WriteHTML(path string) error {
+	var elapsed, offered, confirmed, p50, p99, mempool, backlog []float64
+	for _, s := range r.TimeSeries {
+		elapsed = append(elapsed, s.Elapsed)
+		offered = append(offered, s.Offered)
+		confirmed = append(confirmed, s.TPS1s)
+		p50 = append(p50, s.P50)
+		p99 = append(p99, s.P99)
+		mempool = append(mempool, float64(s.Mempool))
+		backlog = append(backlog, float64(s.Backlog))
+	}
+	var blockNums, fill, txs []float64
+	for _, b := range r.Blocks {
+		blockNums = append(blockNums, float64(b.Number))
+		fill = append(fill, fullness(b))
+		txs = append(txs, float64(b.Txs))
+	}
+	var failures, errors []bar
+	for reason, n := range r.Failures {
+		failures = append(failures, bar{string(reason), n})
+	}
+	for category, n := range r.Errors {
+		errors = append(errors, bar{category, n})
+	}
+
+	var latency []latencyRow
+	for _, measure := range []string{"ack", "inclusion", "block", "confirmed", "finalized"} {
+		if ls, ok := r.Latency[measure]; ok && ls.Count > 0 {
+			latency = append(latency, latencyRow{Measure: measure, LatencySummary: ls})
+		}
+	}
+
+	var flagged []WorkerSummary
+	for _, w := range r.Workers {
+		if w.Outlier != "" {
+			flagged = append(flagged, w)
+		}
+	}
+
+	data := struct {
+		*Result
+		LatencyRows []latencyRow
+		Outliers    []WorkerSummary
+		Percentiles []string
+		Charts      []template.HTML
+	}{
+		Result:      r,
+		LatencyRows: latency,
+		Outliers:    flagged,
+		Charts: []template.HTML{
+			lineChart("Offered vs confirmed TPS", "elapsed (s)", "tx/s",
+				chartSeries{"offered", elapsed, offered}, chartSeries{"confirmed (1s)", elapsed, confirmed}),
+			lineChart("Inclusion latency, last 10s", "elapsed (s)", "ms",
+				chartSeries{"p50", elapsed, p50}, chartSeries{"p99", elapsed, p99}),
+			lineChart("Mempool pending and tracker backlog", "elapsed (s)", "txs",
+				chartSeries{"mempool", elapsed, mempool}, chartSeries{"tracker backlog", elapsed, backlog}),
+			lineChart("Block fullness", "block", "gas used %", chartSeries{"fullness", blockNums, fill}),
+			lineChart("Block txs", "block", "txs", chartSeries{"txs", blockNums, txs}),
+			barChart("Failures by reason", failures),
+			barChart("RPC errors by category", errors),
+		},
+	}
+	for _, p := range percentiles {
+		data.Percentiles = append(data.Percentiles, fmt.Sprintf("p%v", p))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := reportTemplate.Execute(file, data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+type latencyRow struct {
+	Measure string
+	LatencySummary
+}
+
+// lineChart svg of series sharing the axes
+func lineChart(title, xLabel, yLabel string, series ...chartSeries) template.HTML {
+	minX, maxX, maxY := math.Inf(1), math.Inf(-1), 0.0
+	for _, s := range series {
+		for i := range s.x {
+			minX, maxX = math.Min(minX, s.x[i]), math.Max(maxX, s.x[i])
+			maxY = math.Max(maxY, s.y[i])
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return emptyChart(title)
+	}
+	if maxX == minX {
+		maxX = minX + 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+	plotW, plotH := float64(chartWidth-chartLeft-10), float64(chartHeight-chartBottom-30)
+	px := func(x float64) float64 { return chartLeft + (x-minX)/(maxX-minX)*plotW }
+	py := func(y float64) float64 { return 30 + plotH - y/maxY*plotH }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg" font-family="sans-serif" font-size="11">`, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="16" font-size="14" font-weight="bold">%s</text>`, chartLeft, template.HTMLEscapeString(title))
+	for i := 0; i <= 4; i++ {
+		y := maxY * float64(i) / 4
+		fmt.Fprintf(&b, `<line x1="%d" x2="%.1f" y1="%.1f" y2="%.1f" stroke="#ddd"/>`, chartLeft, px(maxX), py(y), py(y))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, chartLeft-4, py(y)+4, compact(y))
+		x := minX + (maxX-minX)*float64(i)/4
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, px(x), chartHeight-chartBottom+14, compact(x))
+	}
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, chartLeft+plotW/2, chartHeight-2, template.HTMLEscapeString(xLabel))
+	fmt.Fprintf(&b, `<text x="12" y="%.1f" transform="rotate(-90 12 %.1f)" text-anchor="middle">%s</text>`, 30+plotH/2, 30+plotH/2, template.HTMLEscapeString(yLabel))
+	for i, s := range series {
+		color := chartColors[i%len(chartColors)]
+		var points []string
+		for j := range s.x {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", px(s.x[j]), py(s.y[j])))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/><text x="%d" y="%d">%s</text>`,
+			chartLeft+200+i*140, 7, color, chartLeft+214+i*140, 16, template.HTMLEscapeString(s.name))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// barChart horizontal bars, largest first
+func barChart(title string, bars []bar) template.HTML {
+	if len(bars) == 0 {
+		return emptyChart(title)
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].value > bars[j].value })
+	const rowH, labelW = 22, 180
+	maxV := float64(bars[0].value)
+	height := 30 + len(bars)*rowH
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg width="%d" height="%d" xmlns="http://www.w3.org/2000/svg" font-family="sans-serif" font-size="11">`, chartWidth, height)
+	fmt.Fprintf(&b, `<text x="%d" y="16" font-size="14" font-weight="bold">%s</text>`, chartLeft, template.HTMLEscapeString(title))
+	for i, bar := range bars {
+		y := 26 + i*rowH
+		w := 1.0
+		if maxV > 0 {
+			w = math.Max(1, float64(bar.value)/maxV*float64(chartWidth-labelW-80))
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, labelW-6, y+13, template.HTMLEscapeString(bar.label))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="16" fill="%s"/>`, labelW, y, w, chartColors[3])
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%d</text>`, float64(labelW)+w+4, y+13, bar.value)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func emptyChart(title string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<svg width="%d" height="40" xmlns="http://www.w3.org/2000/svg" font-family="sans-serif"><text x="%d" y="16" font-size="14" font-weight="bold">%s</text><text x="%d" y="34" font-size="11" fill="#888">no data</text></svg>`,
+		chartWidth, chartLeft, template.HTMLEscapeString(title), chartLeft))
+}
+
+// compact axis label
+func compact(v float64) string {
+	switch {
+	case v >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case v >= 1e4:
+		return fmt.Sprintf("%.0fk", v/1e3)
+	case v == math.Trunc(v):
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return fmt.Sprintf("%.1f", v)
+	}
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct":    func(ls LatencySummary, key string) string { return fmt.Sprintf("%.1f", ls.Percentiles[key]) },
+	"mul100": func(v float64) float64 { return v * 100 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>evm-bench report {{.StartTime.Format "2006-01-02 15:04:05"}}</title>
+<style>
+body { font-family: sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; margin: 8px 0 24px; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th { background: #f4f4f4; }
+td:first-child, th:first-child { text-align: left; }
+svg { display: block; margin: 16px 0; }
+</style>
+</head>
+<body>
+<h1>evm-bench report</h1>
+<p>{{.StartTime.Format "2006-01-02 15:04:05"}} - {{.EndTime.Format "2006-01-02 15:04:05"}}, {{printf "%.0f" .Summary.Duration}}s on {{.Environment.Hostname}} ({{.Environment.OS}}/{{.Environment.Arch}}, {{.Environment.GoVersion}})</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>workers</th><th>total</th><th>success</th><th>failure</th><th>error rate</th><th>tps</th><th>chain tps</th><th>chain gas/s</th><th>reorgs</th></tr>
+<tr><td>{{.Summary.Workers}}</td><td>{{.Summary.Total}}</td><td>{{.Summary.Success}}</td><td>{{.Summary.Failure}}</td>
+<td>{{printf "%.2f%%" (mul100 .Summary.ErrorRate)}}</td><td>{{printf "%.2f" .Summary.TPS}}</td><td>{{printf "%.2f" .Chain.TPS}}</td>
+<td>{{printf "%.0f" .Chain.GasPerSecond}}</td><td>{{.Chain.Reorgs}}</td></tr>
+</table>
+
+<h2>Latency (ms)</h2>
+<table>
+<tr><th>measure</th><th>count</th><th>mean</th><th>min</th>{{range .Percentiles}}<th>{{.}}</th>{{end}}<th>max</th></tr>
+{{range $row := .LatencyRows}}<tr><td>{{$row.Measure}}</td><td>{{$row.Count}}</td><td>{{printf "%.1f" $row.Mean}}</td><td>{{printf "%.1f" $row.Min}}</td>
+{{range $.Percentiles}}<td>{{pct $row.LatencySummary .}}</td>{{end}}<td>{{printf "%.1f" $row.Max}}</td></tr>
+{{end}}</table>
+
+<h2>Blocks</h2>
+<table>
+<tr><th>blocks</th><th>empty</th><th>fullness avg</th><th>interval p50</th><th>interval p99</th><th>interval max</th><th>base fee min</th><th>base fee max</th></tr>
+<tr><td>{{.Chain.FirstBlock}}-{{.Chain.LastBlock}}</td><td>{{.Chain.EmptyBlocks}}</td><td>{{printf "%.1f%%" .Chain.FullnessAvg}}</td>
+<td>{{printf "%.0fs" .Chain.IntervalP50}}</td><td>{{printf "%.0fs" .Chain.IntervalP99}}</td><td>{{printf "%.0fs" .Chain.IntervalMax}}</td>
+<td>{{.Chain.BaseFeeMin}}</td><td>{{.Chain.BaseFeeMax}}</td></tr>
+</table>
+
+{{if .Outliers}}<h2>Outlier workers</h2>
+<table>
+<tr><th>worker</th><th>sent</th><th>success</th><th>failure</th><th>in-flight</th><th>tps</th><th>p50 ms</th><th>outlier</th></tr>
+{{range .Outliers}}<tr><td>{{.Worker}}</td><td>{{.Sent}}</td><td>{{.Success}}</td><td>{{.Failure}}</td><td>{{.InFlight}}</td>
+<td>{{printf "%.2f" .TPS}}</td><td>{{pct .Latency "p50"}}</td><td>{{.Outlier}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Charts</h2>
+{{range .Charts}}{{.}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,255 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets upper bounds of the prometheus latency histograms, seconds
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 120}
+
+// Metrics live metrics of a run, served to prometheus and shown on the dashboard.
+// All methods are safe on a nil *Metrics
+type Metrics struct {
+	sent      atomic.Uint64
+	accepted  atomic.Uint64
+	confirmed atomic.Uint64
+	pending   atomic.Int64  // mempool pending txs
+	queued    atomic.Int64  // txs handed to the tracker, not taken yet
+	tracked   atomic.Int64  // txs held by the tracker until receipt/finality
+	targetTPS atomic.Uint64 // math.Float64bits
+
+	mutex     sync.Mutex
+	failed    map[FailReason]uint64
+	rpcErrors map[string]uint64
+	latency   map[string]*promHistogram // measure -> histogram
+	workers   map[int]*workerStatus     // worker id -> status, for the dashboard
+	recent    []recentError             // last failures, oldest first
+}
+
+// workerStatus live status of one sender
+type workerStatus struct {
+	id     int
+	nonce  uint64 // next nonce
+	sent   uint64
+	done   uint64 // succeeded or failed
+	failed uint64
+}
+
+// recentError one failure shown on the dashboard
+type recentError struct {
+	time    time.Time
+	worker  int
+	message string
+}
+
+// recentErrors failures kept for the dashboard
+const recentErrors = 8
+
+type promHistogram struct {
+	counts []uint64 // per bucket, not cumulative, last is +Inf
+	sum    float64
+	count  uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		failed:    make(map[FailReason]uint64),
+		rpcErrors: make(map[string]uint64),
+		latency:   make(map[string]*promHistogram),
+		workers:   make(map[int]*workerStatus),
+	}
+}
+
+// Sent a tx of worker written to the RPC, nonce is the next nonce of the worker
+func (m *Metrics) Sent(worker int, nonce uint64) {
+	if m == nil {
+		return
+	}
+	m.sent.Add(1)
+	m.mutex.Lock()
+	ws := m.worker(worker)
+	ws.sent++
+	ws.nonce = nonce
+	m.mutex.Unlock()
+}
+
+// Accepted a tx acked by eth_sendRawTransaction
+func (m *Metrics) Accepted() {
+	if m != nil {
+		m.accepted.Add(1)
+	}
+}
+
+// SetPending mempool pending txs
+func (m *Metrics) SetPending(n int) {
+	if m != nil {
+		m.pending.Store(int64(n))
+	}
+}
+
+// SetBacklog txs queued for the tracker and held by it
+func (m *Metrics) SetBacklog(queued, tracked int) {
+	if m != nil {
+		m.queued.Store(int64(queued))
+		m.tracked.Store(int64(tracked))
+	}
+}
+
+// SetTargetTPS offered tx rate
+func (m *Metrics) SetTargetTPS(tps float64) {
+	if m != nil {
+		m.targetTPS.Store(math.Float64bits(tps))
+	}
+}
+
+// senders total sent txs and mempool pending, for the time series
+func (m *Metrics) senders() (sent uint64, pending int64) {
+	if m == nil {
+		return 0, 0
+	}
+	return m.sent.Load(), m.pending.Load()
+}
+
+// backlog of the tracker, queued and tracked, for the time series
+func (m *Metrics) backlog() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.queued.Load() + m.tracked.Load()
+}
+
+// record a completed result, called by the statistics pipeline
+func (m *Metrics) record(tr *TestResult) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ws := m.worker(tr.ChanId)
+	ws.done++
+	if tr.Success {
+		m.confirmed.Add(1)
+		m.observe("ack", tr.AckCost())
+		m.observe("inclusion", tr.Cost)
+		if tr.ConfirmCost() > 0 {
+			m.observe("confirmed", tr.ConfirmCost())
+		}
+		if tr.FinalizeCost() > 0 {
+			m.observe("finalized", tr.FinalizeCost())
+		}
+		return
+	}
+	ws.failed++
+	m.failed[tr.Failure]++
+	if tr.Failure == FailRejected {
+		m.rpcErrors[ClassifyError(tr.Error)]++
+	}
+	message := string(tr.Failure)
+	if tr.Error != "" {
+		message += ": " + tr.Error
+	}
+	if len(m.recent) == recentErrors {
+		m.recent = m.recent[1:]
+	}
+	m.recent = append(m.recent, recentError{time: time.Now(), worker: tr.ChanId, message: message})
+}
+
+// worker must hold mutex
+func (m *Metrics) worker(id int) *workerStatus {
+	ws, ok := m.workers[id]
+	if !ok {
+		ws = &workerStatus{id: id}
+		m.workers[id] = ws
+	}
+	return ws
+}
+
+// workerStatuses copies of the worker statuses ordered by id, and the recent failures
+func (m *Metrics) workerStatuses() ([]workerStatus, []recentError) {
+	if m == nil {
+		return nil, nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	statuses := make([]workerStatus, 0, len(m.workers))
+	for _, ws := range m.workers {
+		statuses = append(statuses, *ws)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].id < statuses[j].id })
+	return statuses, append([]recentError(nil), m.recent...)
+}
+
+// observe must hold mutex
+func (m *Metrics) observe(measure string, d time.Duration) {
+	h, ok := m.latency[measure]
+	if !ok {
+		h = &promHistogram{counts: make([]uint64, len(latencyBuckets)+1)}
+		m.latency[measure] = h
+	}
+	v := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, v)
+	h.counts[i]++
+	h.sum += v
+	h.count++
+}
+
+// ServeHTTP writes metrics in the prometheus text format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	sent, confirmed := m.sent.Load(), m.confirmed.Load()
+	fmt.Fprintf(w, "# TYPE evm_bench_txs_sent_total counter\nevm_bench_txs_sent_total %d\n", sent)
+	fmt.Fprintf(w, "# TYPE evm_bench_txs_accepted_total counter\nevm_bench_txs_accepted_total %d\n", m.accepted.Load())
+	fmt.Fprintf(w, "# TYPE evm_bench_txs_confirmed_total counter\nevm_bench_txs_confirmed_total %d\n", confirmed)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var failed uint64
+	fmt.Fprintf(w, "# TYPE evm_bench_txs_failed_total counter\n")
+	for _, reason := range sortedKeys(m.failed) {
+		failed += m.failed[reason]
+		fmt.Fprintf(w, "evm_bench_txs_failed_total{reason=%q} %d\n", reason, m.failed[reason])
+	}
+	fmt.Fprintf(w, "# TYPE evm_bench_rpc_errors_total counter\n")
+	for _, category := range sortedKeys(m.rpcErrors) {
+		fmt.Fprintf(w, "evm_bench_rpc_errors_total{category=%q} %d\n", category, m.rpcErrors[category])
+	}
+
+	inFlight := int64(sent) - int64(confirmed) - int64(failed)
+	fmt.Fprintf(w, "# TYPE evm_bench_txs_in_flight gauge\nevm_bench_txs_in_flight %d\n", inFlight)
+	fmt.Fprintf(w, "# TYPE evm_bench_mempool_pending gauge\nevm_bench_mempool_pending %d\n", m.pending.Load())
+	fmt.Fprintf(w, "# TYPE evm_bench_tracker_backlog gauge\n")
+	fmt.Fprintf(w, "evm_bench_tracker_backlog{state=\"queued\"} %d\n", m.queued.Load())
+	fmt.Fprintf(w, "evm_bench_tracker_backlog{state=\"tracked\"} %d\n", m.tracked.Load())
+	fmt.Fprintf(w, "# TYPE evm_bench_target_tps gauge\nevm_bench_target_tps %g\n", math.Float64frombits(m.targetTPS.Load()))
+
+	fmt.Fprintf(w, "# TYPE evm_bench_tx_latency_seconds histogram\n")
+	for _, measure := range sortedKeys(m.latency) {
+		h := m.latency[measure]
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "evm_bench_tx_latency_seconds_bucket{measure=%q,le=\"%g\"} %d\n", measure, le, cumulative)
+		}
+		fmt.Fprintf(w, "evm_bench_tx_latency_seconds_bucket{measure=%q,le=\"+Inf\"} %d\n", measure, h.count)
+		fmt.Fprintf(w, "evm_bench_tx_latency_seconds_sum{measure=%q} %g\n", measure, h.sum)
+		fmt.Fprintf(w, "evm_bench_tx_latency_seconds_count{measure=%q} %d\n", measure, h.count)
+	}
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
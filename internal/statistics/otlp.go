@@ -0,0 +1,279 @@
+package statistics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	otlpServiceName = "evm-bench"
+	otlpBatchSize   = 512 // spans of txs per export request
+	otlpQueue       = 8   // export requests waiting for a slow collector, later ones are dropped
+)
+
+// OTLP/JSON encoding of ExportTraceServiceRequest, only the fields we emit
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // int64 as decimal string
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 1 ok, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) otlpAttribute {
+	v := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &v}}
+}
+
+// otlpExporter turns every result into a trace of its lifecycle, and exports them in batches
+// as OTLP/JSON lines to a file, and/or to a collector's /v1/traces.
+type otlpExporter struct {
+	file     *os.File
+	buf      *bufio.Writer
+	endpoint string
+	client   *http.Client
+	rand     *rand.Rand
+	spans    []otlpSpan
+	txs      int
+
+	queue   chan []byte   // export requests to the endpoint, posted by a goroutine of its own
+	done    chan struct{} // closed once the queue is posted
+	dropped int           // txs not exported, the queue was full
+	failed  atomic.Uint64 // export requests the collector failed
+}
+
+func newOTLPExporter(path, endpoint string) (*otlpExporter, error) {
+	e := &otlpExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Second * 10},
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if endpoint != "" {
+		e.queue = make(chan []byte, otlpQueue)
+		e.done = make(chan struct{})
+		go e.post()
+	}
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		e.file = file
+		e.buf = bufio.NewWriterSize(file, 64*1024)
+	}
+	return e, nil
+}
+
+// write spans of a tx: sign, submit, pending until first seen in block, receipt,
+// and confirmations/finality if tracked, under one root span
+func (e *otlpExporter) write(tr *TestResult) error {
+	traceId, rootId := e.id(16), e.id(8)
+	end := latest(tr.AckTime, tr.SeenTime, tr.ReceiptTime, tr.ConfirmedTime, tr.FinalizedTime)
+	if !tr.Success && tr.Failure != FailReverted && tr.Failure != FailRejected {
+		// dropped by the tracker just now
+		end = time.Now()
+	}
+
+	root := otlpSpan{
+		TraceId:           traceId,
+		SpanId:            rootId,
+		Name:              "tx",
+		Kind:              3, // client
+		StartTimeUnixNano: unixNano(tr.SignTime),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []otlpAttribute{
+			intAttr("worker", int64(tr.ChanId)),
+			intAttr("nonce", int64(tr.Nonce)),
+			stringAttr("tx.hash", tr.TxHash),
+		},
+		Status: &otlpStatus{Code: 1},
+	}
+	if tr.BlockNum > 0 {
+		root.Attributes = append(root.Attributes, intAttr("block.number", int64(tr.BlockNum)), stringAttr("block.hash", tr.BlockHash))
+	}
+	if tr.Reorged > 0 {
+		root.Attributes = append(root.Attributes, intAttr("reorged", int64(tr.Reorged)))
+	}
+	if tr.Failure != "" {
+		root.Status = &otlpStatus{Code: 2, Message: string(tr.Failure)}
+		root.Attributes = append(root.Attributes, stringAttr("failure", string(tr.Failure)))
+		if tr.Error != "" {
+			root.Attributes = append(root.Attributes, stringAttr("error", tr.Error))
+		}
+	}
+	e.spans = append(e.spans, root)
+
+	child := func(name string, start, end time.Time) {
+		if start.IsZero() || end.IsZero() {
+			return
+		}
+		e.spans = append(e.spans, otlpSpan{
+			TraceId:           traceId,
+			SpanId:            e.id(8),
+			ParentSpanId:      rootId,
+			Name:              name,
+			Kind:              1, // internal
+			StartTimeUnixNano: unixNano(start),
+			EndTimeUnixNano:   unixNano(end),
+		})
+	}
+	child("sign", tr.SignTime, tr.ReqTime)
+	child("submit", tr.ReqTime, tr.AckTime)
+	child("pending", tr.AckTime, tr.SeenTime)
+	child("receipt", tr.SeenTime, tr.ReceiptTime)
+	child("confirmations", tr.SeenTime, tr.ConfirmedTime)
+	child("finality", tr.SeenTime, tr.FinalizedTime)
+
+	e.txs++
+	if e.txs >= otlpBatchSize {
+		return e.flush()
+	}
+	return nil
+}
+
+// flush writes the batched spans to the file, and queues them for the endpoint without waiting
+func (e *otlpExporter) flush() error {
+	if len(e.spans) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(&otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{stringAttr("service.name", otlpServiceName)}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpServiceName}, Spans: e.spans}},
+	}}})
+	txs := e.txs
+	e.spans = e.spans[:0]
+	e.txs = 0
+	if err != nil {
+		return err
+	}
+
+	if e.queue != nil {
+		select {
+		case e.queue <- data:
+		default:
+			e.dropped += txs
+		}
+	}
+	if e.buf != nil {
+		if _, err := e.buf.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		if err := e.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post exports the queued requests, a slow or down collector never holds up the aggregation
+func (e *otlpExporter) post() {
+	defer close(e.done)
+	for data := range e.queue {
+		if err := e.export(data); err != nil && e.failed.Add(1) == 1 {
+			log.Printf("Failed to export otlp traces: %v", err)
+		}
+	}
+}
+
+func (e *otlpExporter) export(data []byte) error {
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp collector %s: %s", e.endpoint, resp.Status)
+	}
+	return nil
+}
+
+func (e *otlpExporter) close() error {
+	err := e.flush()
+	if e.queue != nil {
+		close(e.queue)
+		<-e.done
+		if e.dropped > 0 || e.failed.Load() > 0 {
+			log.Printf("otlp %s: %d txs dropped by a full queue, %d export requests failed", e.endpoint, e.dropped, e.failed.Load())
+		}
+	}
+	if e.file != nil {
+		if cerr := e.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (e *otlpExporter) id(n int) string {
+	b := make([]byte, n)
+	e.rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func latest(times ...time.Time) (t time.Time) {
+	for _, v := range times {
+		if v.After(t) {
+			t = v
+		}
+	}
+	return
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -2,92 +2,14 @@ package statistics
 
 import (
 	"fmt"
+	"io"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
-func HandleStatistics(concurrency uint64, ch <-chan *TestResult) {
-	var (
-		costTimeList    []time.Duration                  // 耗时数组
-		processingTime  time.Duration   = 0              // processingTime 处理总耗时
-		requestCostTime time.Duration   = 0              // requestCostTime 请求总时间
-		maxTime         time.Duration   = 0              // maxTime 至今为止单个请求最大耗时
-		minTime         time.Duration   = 24 * time.Hour // minTime 至今为止单个请求最小耗时
-		successNum      uint64          = 0
-		failureNum      uint64          = 0
-		chanIdLen       uint64          = 0 // chanIdLen 并发数
-		stopChan                        = make(chan bool)
-		mutex                           = sync.RWMutex{}
-		chanIds                         = make(map[int]bool)
-	)
-
-	startTime := time.Now()
-	respCodeMap := sync.Map{}
-	ticker := time.NewTicker(time.Second)
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				curTime := time.Now()
-				mutex.Lock()
-				go calculateData(concurrency, processingTime, curTime.Sub(startTime), maxTime, minTime, successNum, failureNum, chanIdLen, &respCodeMap)
-				mutex.Unlock()
-			case <-stopChan:
-				return
-			}
-		}
-	}()
-
-	printHeader()
-	for respRes := range ch {
-		mutex.Lock()
-
-		// total process time
-		processingTime += respRes.Cost
-		if respRes.Success {
-			successNum = successNum + 1
-			if maxTime <= respRes.Cost {
-				maxTime = respRes.Cost
-			}
-			if minTime > respRes.Cost {
-				minTime = respRes.Cost
-			}
-
-			if _, ok := chanIds[respRes.ChanId]; !ok {
-				chanIds[respRes.ChanId] = true
-				chanIdLen = uint64(len(chanIds))
-			}
-
-			// success cost time, for P90 cal
-			costTimeList = append(costTimeList, respRes.Cost)
-
-		} else {
-			failureNum = failureNum + 1
-		}
-
-		mutex.Unlock()
-	}
-
-	// 数据全部接受完成，停止定时输出统计数据
-	stopChan <- true
-	endTime := time.Now()
-	requestCostTime = endTime.Sub(startTime)
-	calculateData(concurrency, processingTime, requestCostTime, maxTime, minTime, successNum, failureNum, chanIdLen, &respCodeMap)
-
-	fmt.Printf("\n\n")
-	fmt.Println("*************************  结果 stat  ****************************")
-	fmt.Println("处理协程数量:", concurrency)
-	fmt.Printf("请求总数: %d 总请求时间: %.3f秒 successNum: %d failureNum: %d\n",
-		successNum+failureNum, requestCostTime.Seconds(), successNum, failureNum)
-	printTop(costTimeList)
-	fmt.Println("*************************  结果 end   ****************************")
-	fmt.Printf("\n\n")
-}
-
-func calculateData(concurrent uint64, processingTime, costTime, maxTime, minTime time.Duration, successNum, failureNum, chanIdLen uint64, respCodeMap *sync.Map) {
+// calculateData one row of the live table
+func calculateData(concurrent uint64, processingTime, costTime, maxTime, minTime time.Duration, successNum, failureNum, chanIdLen uint64, live, codes, outliers string) string {
 	var qps, averageTime float64
 
 	// QPS: 协程数 * (成功数/处理总耗时)
@@ -100,48 +22,148 @@ func calculateData(concurrent uint64, processingTime, costTime, maxTime, minTime
 		averageTime = processingTime.Seconds() / float64(successNum)
 	}
 
-	result := fmt.Sprintf("%4.0fs│%7d│%7d│%7d│%8.2f│%10.2fs│%10.2fs│%10.2fs│%v",
-		costTime.Seconds(), chanIdLen, successNum, failureNum, qps, averageTime, minTime.Seconds(), maxTime.Seconds(), printMap(respCodeMap))
-	fmt.Println(result)
+	result := fmt.Sprintf("%4.0fs│%7d│%7d│%7d│%8.2f│%10.2fs│%10.2fs│%10.2fs│%s│%v\n",
+		costTime.Seconds(), chanIdLen, successNum, failureNum, qps, averageTime, minTime.Seconds(), maxTime.Seconds(), live, codes)
+	if outliers != "" {
+		result += fmt.Sprintf("     │ outliers: %s\n", outliers)
+	}
+	return result
 }
 
-func printHeader() {
-	fmt.Printf("\n\n")
-	fmt.Println("─────┬───────┬───────┬───────┬────────┬───────────┬───────────┬───────────┬────────")
-	fmt.Println(" cost│concurr│success│ failed│   qps  │ avg cost/s│ min cost/s│max cost/ms│ xxx    ")
-	fmt.Println("─────┼───────┼───────┼───────┼────────┼───────────┼───────────┼───────────┼────────")
+func printHeader(out io.Writer) {
+	fmt.Fprintf(out, "\n\n")
+	fmt.Fprintln(out, "─────┬───────┬───────┬───────┬────────┬───────────┬───────────┬───────────┬───────┬───────┬───────┬─────────┬─────────┬─────────┬────────")
+	fmt.Fprintln(out, " cost│concurr│success│ failed│   qps  │ avg cost/s│ min cost/s│max cost/ms│ tps 1s│tps 10s│tps 60s│ p50 10s │ p99 10s │ p99 60s │ errors 1s/total")
+	fmt.Fprintln(out, "─────┼───────┼───────┼───────┼────────┼───────────┼───────────┼───────────┼───────┼───────┼───────┼─────────┼─────────┼─────────┼────────")
 	return
 }
 
-// 打印响应状态码及数量, 如 200:5
-func printMap(respCodeMap *sync.Map) (mapStr string) {
+// printWindows 最近 1s/10s/60s 的 tps 及 10s/60s 的延迟分位
+func printWindows(window *windows, now time.Time) string {
+	w1, w10, w60 := window.stat(now, 1), window.stat(now, 10), window.stat(now, 60)
+	return fmt.Sprintf("%7.1f│%7.1f│%7.1f│%8.2fs│%8.2fs│%8.2fs",
+		w1.tps, w10.tps, w60.tps, w10.latency.Percentile(50).Seconds(), w10.latency.Percentile(99).Seconds(), w60.latency.Percentile(99).Seconds())
+}
+
+// sample live statistics of the last second for the time series
+func sample(window *windows, now time.Time, elapsed time.Duration, successNum, failureNum uint64) Sample {
+	w1, w10 := window.stat(now, 1), window.stat(now, 10)
+	return Sample{
+		Elapsed: elapsed.Seconds(),
+		Success: successNum,
+		Failure: failureNum,
+		TPS1s:   w1.tps,
+		TPS10s:  w10.tps,
+		P50:     toMs(w10.latency.Percentile(50)),
+		P99:     toMs(w10.latency.Percentile(99)),
+	}
+}
+
+// 打印 RPC 错误类别及本秒/总数量, 如 nonce too low:3/120, last 记录上次的总数量
+func printMap(respCodeMap map[string]uint64, last map[string]uint64) (mapStr string) {
 	var mapArr []string
 
-	respCodeMap.Range(func(key, value interface{}) bool {
-		mapArr = append(mapArr, fmt.Sprintf("%v:%v", key, value))
-		return true
-	})
+	for key, total := range respCodeMap {
+		mapArr = append(mapArr, fmt.Sprintf("%v:%d/%d", key, total-last[key], total))
+		last[key] = total
+	}
 	sort.Strings(mapArr)
 	mapStr = strings.Join(mapArr, ";")
 	return
 }
 
-// printTop top 90 95 99
-func printTop(costTimeList []time.Duration) {
-	if len(costTimeList) == 0 {
+type durationArray []time.Duration
+
+func (array durationArray) Len() int           { return len(array) }
+func (array durationArray) Swap(i, j int)      { array[i], array[j] = array[j], array[i] }
+func (array durationArray) Less(i, j int) bool { return array[i] < array[j] }
+
+// percentiles printed for each latency measure
+var percentiles = []float64{50, 90, 95, 99, 99.9}
+
+// printHistogram avg/min/percentiles/max of a latency measure, skipped if not tracked
+func printHistogram(name string, h *Histogram, format func(time.Duration) string) {
+	if h.Count() == 0 {
 		return
 	}
+	line := fmt.Sprintf("%s avg: %s min: %s", name, format(h.Mean()), format(h.Min()))
+	for _, p := range percentiles {
+		line += fmt.Sprintf(" P%v: %s", p, format(h.Percentile(p)))
+	}
+	line += fmt.Sprintf(" max: %s", format(h.Max()))
+	fmt.Println(line)
+}
 
-	all := durationArray{}
-	all = costTimeList
-	sort.Sort(all)
-	fmt.Println("P90:", fmt.Sprintf("%.2fs", all[int(float64(len(all))*0.90)].Seconds()))
-	fmt.Println("P95:", fmt.Sprintf("%.2fs", all[int(float64(len(all))*0.95)].Seconds()))
-	fmt.Println("P99:", fmt.Sprintf("%.2fs", all[int(float64(len(all))*0.99)].Seconds()))
+// ms local clock measures
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", d.Seconds()*1000)
 }
 
-type durationArray []time.Duration
+// sec block timestamp measures, 1s resolution
+func sec(d time.Duration) string {
+	return fmt.Sprintf("%.0fs", d.Seconds())
+}
 
-func (array durationArray) Len() int           { return len(array) }
-func (array durationArray) Swap(i, j int)      { array[i], array[j] = array[j], array[i] }
-func (array durationArray) Less(i, j int) bool { return array[i] < array[j] }
+// printFailures 打印失败原因及数量, 如 timeout:5
+func printFailures(failures map[FailReason]uint64) {
+	if len(failures) == 0 {
+		return
+	}
+	var arr []string
+	for reason, num := range failures {
+		arr = append(arr, fmt.Sprintf("%s:%d", reason, num))
+	}
+	sort.Strings(arr)
+	fmt.Println("failures:", strings.Join(arr, ";"))
+}
+
+// printErrors 打印 RPC 错误类别总数量, 按数量降序
+func printErrors(respCodeMap map[string]uint64) {
+	type code struct {
+		category string
+		total    uint64
+	}
+	var codes []code
+	for category, total := range respCodeMap {
+		codes = append(codes, code{category, total})
+	}
+	if len(codes) == 0 {
+		return
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].total > codes[j].total })
+	fmt.Println("RPC errors:")
+	for _, c := range codes {
+		fmt.Printf("  %-24s %d\n", c.category, c.total)
+	}
+}
+
+// amount min/max/avg of one receipt value
+type amount struct {
+	total uint64
+	min   uint64
+	max   uint64
+	count uint64
+}
+
+func (a *amount) add(v uint64) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.total += v
+	a.count++
+}
+
+func (a *amount) avg() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return float64(a.total) / float64(a.count)
+}
+
+func printGas(gasUsed, gasPrice amount) {
+	fmt.Printf("gasUsed: total: %d avg: %.0f min: %d max: %d\n", gasUsed.total, gasUsed.avg(), gasUsed.min, gasUsed.max)
+	fmt.Printf("effectiveGasPrice: avg: %.0f min: %d max: %d wei\n", gasPrice.avg(), gasPrice.min, gasPrice.max)
+}
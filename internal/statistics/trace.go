@@ -0,0 +1,99 @@
+package statistics
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// traceRecord one line of the trace log
+type traceRecord struct {
+	Worker        int        `json:"worker"`
+	Nonce         uint64     `json:"nonce"`
+	Hash          string     `json:"hash"`
+	SubmitTime    time.Time  `json:"submitTime"`
+	AckTime       *time.Time `json:"ackTime,omitempty"`
+	Block         uint64     `json:"block,omitempty"`
+	BlockHash     string     `json:"blockHash,omitempty"`
+	BlockTime     *time.Time `json:"blockTime,omitempty"`
+	InclusionTime *time.Time `json:"inclusionTime,omitempty"` // local time the block head arrived
+	ConfirmedTime *time.Time `json:"confirmedTime,omitempty"`
+	FinalizedTime *time.Time `json:"finalizedTime,omitempty"`
+	CostMs        float64    `json:"costMs"`
+	Success       bool       `json:"success"`
+	Status        *uint64    `json:"status,omitempty"` // receipt status, absent without receipt
+	Failure       FailReason `json:"failure,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	GasUsed       uint64     `json:"gasUsed,omitempty"`
+	GasPrice      uint64     `json:"gasPrice,omitempty"`
+	Logs          int        `json:"logs,omitempty"`
+	Reorged       int        `json:"reorged,omitempty"`
+}
+
+// traceWriter streams every result as a JSON line, memory is bounded by the buffer
+type traceWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+}
+
+func newTraceWriter(path string) (*traceWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriterSize(file, 64*1024)
+	return &traceWriter{file: file, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+func (tw *traceWriter) write(tr *TestResult) error {
+	record := traceRecord{
+		Worker:        tr.ChanId,
+		Nonce:         tr.Nonce,
+		Hash:          tr.TxHash,
+		SubmitTime:    tr.ReqTime,
+		AckTime:       optionalTime(tr.AckTime),
+		Block:         tr.BlockNum,
+		BlockHash:     tr.BlockHash,
+		BlockTime:     optionalTime(tr.BlockTime),
+		InclusionTime: optionalTime(tr.SeenTime),
+		ConfirmedTime: optionalTime(tr.ConfirmedTime),
+		FinalizedTime: optionalTime(tr.FinalizedTime),
+		CostMs:        toMs(tr.Cost),
+		Success:       tr.Success,
+		Failure:       tr.Failure,
+		Error:         tr.Error,
+		GasUsed:       tr.GasUsed,
+		GasPrice:      tr.GasPrice,
+		Logs:          tr.Logs,
+		Reorged:       tr.Reorged,
+	}
+	if tr.Success || tr.Failure == FailReverted {
+		var status uint64
+		if tr.Success {
+			status = 1
+		}
+		record.Status = &status
+	}
+	return tw.enc.Encode(&record)
+}
+
+func (tw *traceWriter) flush() error {
+	return tw.buf.Flush()
+}
+
+func (tw *traceWriter) close() error {
+	if err := tw.buf.Flush(); err != nil {
+		_ = tw.file.Close()
+		return err
+	}
+	return tw.file.Close()
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
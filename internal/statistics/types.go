@@ -5,16 +5,67 @@ import (
 	"time"
 )
 
+// FailReason why a tx did not succeed
+type FailReason string
+
+const (
+	FailRejected FailReason = "rejected" // eth_sendRawTransaction returned error
+	FailEvicted  FailReason = "evicted"  // gone from mempool without inclusion
+	FailTimeout  FailReason = "timeout"  // still not included at deadline, or no receipt
+	FailReverted FailReason = "reverted" // included with receipt status 0
+)
+
 type TestResult struct {
-	ChanId   int
-	Nonce    uint64        // id
-	TxHash   string        // tx hash
-	BlockNum uint64        // block number
-	ReqTime  time.Time     // request time
-	Cost     time.Duration // total cost
-	Success  bool          // success
+	ChanId        int
+	Nonce         uint64        // id
+	TxHash        string        // tx hash
+	BlockNum      uint64        // block number
+	BlockHash     string        // block hash
+	SignTime      time.Time     // before signing
+	ReqTime       time.Time     // request time, before submit
+	AckTime       time.Time     // eth_sendRawTransaction accepted by node
+	SeenTime      time.Time     // local time the inclusion block head arrived
+	BlockTime     time.Time     // inclusion block timestamp, 1s resolution
+	ConfirmedTime time.Time     // local time the required confirmations reached
+	FinalizedTime time.Time     // local time the safe/finalized block reached the tx
+	ReceiptTime   time.Time     // local time the receipt fetched
+	Cost          time.Duration // total cost, submit to first seen in block
+	Success       bool          // success, included with receipt status 1
+	Failure       FailReason    // empty unless failed
+	Error         string        // RPC error message of a rejected tx
+	GasUsed       uint64        // receipt gasUsed
+	GasPrice      uint64        // receipt effectiveGasPrice, wei
+	Logs          int           // receipt logs count
+	Reorged       int           // times knocked out of a block by reorg
+	Expired       bool          // passed the inclusion deadline, being looked up
+}
+
+// AckCost submit to RPC accepted
+func (tr *TestResult) AckCost() time.Duration {
+	return tr.AckTime.Sub(tr.ReqTime)
+}
+
+// BlockCost submit to inclusion block timestamp, may be negative due to 1s resolution
+func (tr *TestResult) BlockCost() time.Duration {
+	return tr.BlockTime.Sub(tr.ReqTime)
+}
+
+// ConfirmCost submit to N confirmations, zero if not tracked
+func (tr *TestResult) ConfirmCost() time.Duration {
+	if tr.ConfirmedTime.IsZero() {
+		return 0
+	}
+	return tr.ConfirmedTime.Sub(tr.ReqTime)
+}
+
+// FinalizeCost submit to safe/finalized, zero if not tracked
+func (tr *TestResult) FinalizeCost() time.Duration {
+	if tr.FinalizedTime.IsZero() {
+		return 0
+	}
+	return tr.FinalizedTime.Sub(tr.ReqTime)
 }
 
 func (tr *TestResult) String() string {
-	return fmt.Sprintf("Nonce:%d ReqTime:%s Success:%v Cost:%.3fs", tr.Nonce, tr.ReqTime.Format("04:05.000"), tr.Success, tr.Cost.Seconds())
+	return fmt.Sprintf("Nonce:%d ReqTime:%s Success:%v Failure:%s Cost:%.3fs", tr.Nonce, tr.ReqTime.Format("04:05.000"), tr.Success, tr.Failure, tr.Cost.Seconds())
 }
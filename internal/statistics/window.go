@@ -0,0 +1,68 @@
+package statistics
+
+import (
+	"time"
+)
+
+// windowBucket results completed in one second
+type windowBucket struct {
+	second  int64
+	success uint64
+	failure uint64
+	latency *Histogram
+}
+
+// windows per second buckets of the last seconds, for rolling live statistics
+type windows struct {
+	start   int64
+	buckets []windowBucket // ring indexed by unix second
+}
+
+func newWindows(start time.Time, seconds int) *windows {
+	return &windows{start: start.Unix(), buckets: make([]windowBucket, seconds)}
+}
+
+func (w *windows) add(now time.Time, res *TestResult) {
+	second := now.Unix()
+	b := &w.buckets[second%int64(len(w.buckets))]
+	if b.second != second {
+		*b = windowBucket{second: second, latency: NewHistogram()}
+	}
+	if res.Success {
+		b.success++
+		b.latency.Record(res.Cost)
+	} else {
+		b.failure++
+	}
+}
+
+// windowStat results of the last full seconds
+type windowStat struct {
+	tps     float64
+	failure uint64
+	latency *Histogram
+}
+
+// stat merges the last seconds before now, shorter at the beginning of the run
+func (w *windows) stat(now time.Time, seconds int) windowStat {
+	end := now.Unix()
+	from := end - int64(seconds)
+	if from < w.start {
+		from = w.start
+	}
+	ws := windowStat{latency: NewHistogram()}
+	if end <= from {
+		return ws
+	}
+	for second := from; second < end; second++ {
+		b := &w.buckets[second%int64(len(w.buckets))]
+		if b.second != second {
+			continue
+		}
+		ws.tps += float64(b.success)
+		ws.failure += b.failure
+		ws.latency.Merge(b.latency)
+	}
+	ws.tps /= float64(end - from)
+	return ws
+}
@@ -0,0 +1,174 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	stallAfter   = time.Second * 10 // in flight without any result, e.g. stuck behind a nonce gap
+	lowTPSRatio  = 0.5              // of the median worker tps
+	slowP50Ratio = 2.0              // of the median worker p50
+	minErrorRate = 0.05             // flagged above max(2 * overall, minErrorRate)
+)
+
+// workerStats results of one sender
+type workerStats struct {
+	success  uint64
+	failure  uint64
+	latency  *Histogram // submit->seen of success
+	lastTime time.Time  // last result
+}
+
+func (ws *workerStats) add(now time.Time, tr *TestResult) {
+	ws.lastTime = now
+	if tr.Success {
+		ws.success++
+		ws.latency.Record(tr.Cost)
+	} else {
+		ws.failure++
+	}
+}
+
+// WorkerSummary results of one sender, Outlier tells why it stands out from the others
+type WorkerSummary struct {
+	Worker   int            `json:"worker"`
+	Sent     uint64         `json:"sent"`
+	Success  uint64         `json:"success"`
+	Failure  uint64         `json:"failure"`
+	InFlight int64          `json:"inFlight"`
+	TPS      float64        `json:"tps"`
+	Latency  LatencySummary `json:"latency"` // inclusion, ms
+	Outlier  string         `json:"outlier,omitempty"`
+}
+
+// summarizeWorkers per worker summaries ordered by id. Workers only known to the metrics,
+// with no result at all, are included.
+func summarizeWorkers(workers map[int]*workerStats, metrics *Metrics, start, now time.Time) []WorkerSummary {
+	statuses, _ := metrics.workerStatuses()
+	sent := make(map[int]uint64, len(statuses))
+	// workers which sent and have no results yet too, workers of the caller is left as is
+	all := make(map[int]*workerStats, len(workers)+len(statuses))
+	for id, ws := range workers {
+		all[id] = ws
+	}
+	for _, s := range statuses {
+		sent[s.id] = s.sent
+		if _, ok := all[s.id]; !ok {
+			all[s.id] = &workerStats{latency: NewHistogram()}
+		}
+	}
+
+	elapsed := now.Sub(start).Seconds()
+	summaries := make([]WorkerSummary, 0, len(all))
+	idle := make(map[int]time.Duration, len(all))
+	var success, failure uint64
+	for id, ws := range all {
+		s := WorkerSummary{
+			Worker:  id,
+			Sent:    sent[id],
+			Success: ws.success,
+			Failure: ws.failure,
+			Latency: latencySummary(ws.latency),
+		}
+		if metrics == nil {
+			s.Sent = ws.success + ws.failure
+		}
+		s.InFlight = int64(s.Sent) - int64(ws.success+ws.failure)
+		if elapsed > 0 {
+			s.TPS = float64(ws.success) / elapsed
+		}
+		last := ws.lastTime
+		if last.IsZero() {
+			last = start
+		}
+		idle[id] = now.Sub(last)
+		success += ws.success
+		failure += ws.failure
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Worker < summaries[j].Worker })
+
+	var errorRate float64
+	if success+failure > 0 {
+		errorRate = float64(failure) / float64(success+failure)
+	}
+	flagOutliers(summaries, idle, errorRate)
+	return summaries
+}
+
+// flagOutliers marks workers far from the median of all workers
+func flagOutliers(summaries []WorkerSummary, idle map[int]time.Duration, errorRate float64) {
+	var tps, p50 []float64
+	for _, s := range summaries {
+		tps = append(tps, s.TPS)
+		if s.Success > 0 {
+			p50 = append(p50, s.Latency.Percentiles["p50"])
+		}
+	}
+	medianTPS, medianP50 := median(tps), median(p50)
+	maxErrorRate := math.Max(2*errorRate, minErrorRate)
+
+	for i := range summaries {
+		s := &summaries[i]
+		total := s.Success + s.Failure
+		switch {
+		case s.InFlight > 0 && idle[s.Worker] >= stallAfter:
+			s.Outlier = fmt.Sprintf("stalled %ds, %d in flight", int(idle[s.Worker].Seconds()), s.InFlight)
+		case len(summaries) > 1 && medianTPS > 0 && s.TPS < medianTPS*lowTPSRatio:
+			s.Outlier = fmt.Sprintf("low tps %.1f, median %.1f", s.TPS, medianTPS)
+		case len(p50) > 1 && s.Success > 0 && s.Latency.Percentiles["p50"] > medianP50*slowP50Ratio:
+			s.Outlier = fmt.Sprintf("slow p50 %.0fms, median %.0fms", s.Latency.Percentiles["p50"], medianP50)
+		case total > 0 && float64(s.Failure)/float64(total) > maxErrorRate:
+			s.Outlier = fmt.Sprintf("errors %.1f%%", float64(s.Failure)/float64(total)*100)
+		}
+	}
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// outliers flagged workers, for the live table, e.g. w3 stalled 12s, 400 in flight
+func outliers(summaries []WorkerSummary) string {
+	var arr []string
+	for _, s := range summaries {
+		if s.Outlier != "" {
+			arr = append(arr, fmt.Sprintf("w%d %s", s.Worker, s.Outlier))
+		}
+	}
+	return strings.Join(arr, "; ")
+}
+
+// printWorkers all workers if few, otherwise only the flagged ones
+func printWorkers(summaries []WorkerSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+	fmt.Println("workers:")
+	fmt.Println("  worker │    sent │ success │ failure │ in-flight │    tps │    p50 │    p99 │ outlier")
+	var hidden int
+	for _, s := range summaries {
+		if len(summaries) > maxWorkerRows && s.Outlier == "" {
+			hidden++
+			continue
+		}
+		fmt.Printf("  %6d │ %7d │ %7d │ %7d │ %9d │ %6.1f │ %6s │ %6s │ %s\n", s.Worker, s.Sent, s.Success, s.Failure, s.InFlight, s.TPS,
+			fmt.Sprintf("%.2fs", s.Latency.Percentiles["p50"]/1000), fmt.Sprintf("%.2fs", s.Latency.Percentiles["p99"]/1000), s.Outlier)
+	}
+	if hidden > 0 {
+		fmt.Printf("  ... %d workers within normal range\n", hidden)
+	}
+}
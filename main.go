@@ -1,9 +1,17 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 	"github.io/kevin-rd/evm-bench/eth"
+	"github.io/kevin-rd/evm-bench/internal/keys"
 	"github.io/kevin-rd/evm-bench/internal/statistics"
 	"log"
+	"math/big"
+	"net/http"
+	"os"
 	"sync"
 	"time"
 )
@@ -18,44 +26,197 @@ const (
 	recipientAddr = "0x2344991936359AAcaAC175198F556c08cd74dF55"
 )
 
-var accounts = []string{
-	"ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
-	"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
-	"5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a",
-	"7c852118294e51e653712a81e05800f419141751be58f605c371e15141b007a6",
-	"47e179ec197488593b187f80a00eb0da91f1b9d0b13f8733639f19c30a34926a",
+var (
+	resultJSON   = flag.String("json", "", "write the result document as JSON to this file")
+	resultCSV    = flag.String("csv", "", "write per tx records as CSV to this file")
+	traceLog     = flag.String("trace", "", "stream every tx result as JSON lines to this file")
+	htmlReport   = flag.String("html", "", "write a self-contained HTML report with charts to this file")
+	otlpFile     = flag.String("otlp-file", "", "write tx lifecycle traces as OTLP/JSON lines to this file")
+	otlpEndpoint = flag.String("otlp-endpoint", "", "export tx lifecycle traces to an OTLP/HTTP collector, e.g. http://127.0.0.1:4318/v1/traces")
+	metricsAddr  = flag.String("metrics", "", "serve prometheus /metrics on this address, e.g. :9100")
+	tui          = flag.Bool("tui", false, "redraw a live dashboard in place, the plain log goes to -log")
+	logFile      = flag.String("log", "", "write the plain log to this file, default evm-bench.log with -tui")
+
+	confirmations = flag.Uint64("confirmations", 0, "blocks on top of the inclusion block before a tx counts as confirmed, 0 disable")
+	finalityTag   = flag.String("finality", "", `wait for the "safe" or "finalized" block to include a tx, empty disable`)
+	txTimeout     = flag.Duration("tx-timeout", time.Second*60, "deadline of each tracking stage, inclusion, receipt and finality, 0 disable")
+
+	numAccounts  = flag.Int("accounts", 0, "number of sender accounts, 0 all loaded keys or 5 derived")
+	keystoreDir  = flag.String("keystore", "", "load the sender keys from the go-ethereum keystore files of this dir, or a single file")
+	passwordFile = flag.String("password", "", "password file of -keystore, one password for all or one line per key file")
+	keysFile     = flag.String("keys", "", "load the sender keys from this file, one hex key per line")
+	mnemonic     = flag.String("mnemonic", "", "derive the sender accounts from this BIP-39 mnemonic, or $"+envMnemonic)
+	hdPath       = flag.String("hd-path", keys.DefaultHDPath, "HD base path of the derived accounts, the account index is appended")
+	seed         = flag.String("seed", "", "derive the sender accounts deterministically from this seed")
+
+	numSenders     = flag.Int("senders", 0, "number of sender goroutines, the accounts are split among them, 0 one per account")
+	numConnections = flag.Int("connections", 0, "number of websocket connections shared by the senders, 0 one per sender")
+
+	faucetKey  = flag.String("faucet", "", "hex private key of a funded account, or $"+envFaucetKey+", tops up every sender account before the run")
+	fundAmount = flag.String("fund", "1", "balance in ether every sender account is topped up to by -faucet")
+	sweep      = flag.Bool("sweep", false, "send the balances of the sender accounts back to -faucet after the run")
+)
+
+// config recorded into the result document
+type config struct {
+	WsURL         string `json:"wsURL"`
+	RpcAddr       string `json:"rpcAddr"`
+	Accounts      int    `json:"accounts"`
+	KeySource     string `json:"keySource"` // keystore, keys file, env, mnemonic, seed or dev mnemonic
+	HDPath        string `json:"hdPath,omitempty"`
+	Senders       int    `json:"senders"`
+	Connections   int    `json:"connections"`
+	Fund          string `json:"fund,omitempty"` // ether each account was topped up to
+	MaxPending    int    `json:"maxPending"`
+	PressDuration string `json:"pressDuration"`
+	Confirmations uint64 `json:"confirmations"`
+	FinalityTag   string `json:"finalityTag"`
+	TxTimeout     string `json:"txTimeout"`
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(runCompare(os.Args[2:]))
+	}
+	flag.Parse()
+	switch *finalityTag {
+	case "", "safe", "finalized":
+	default:
+		log.Fatalf("Invalid -finality %s, want safe or finalized", *finalityTag)
+	}
+
+	if *tui && *logFile == "" {
+		*logFile = "evm-bench.log"
+	}
+	if *logFile != "" {
+		file, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("Failed to open log %s: %v", *logFile, err)
+		}
+		defer file.Close()
+		log.SetOutput(file)
+	}
+
+	senderKeys, keySource, err := loadAccounts()
+	if err != nil {
+		log.Fatalf("Failed to load accounts: %v", err)
+	}
+	log.Printf("%d sender accounts from %s", len(senderKeys), keySource)
+	faucetHex := faucetKeyOf()
+
+	accounts := make([]*eth.Account, len(senderKeys))
+	for i, key := range senderKeys {
+		if accounts[i], err = eth.NewAccount(i, key); err != nil {
+			log.Fatal(err)
+		}
+	}
+	senders, connections := topologyOf(len(accounts))
+	log.Printf("%d accounts over %d senders and %d connections", len(accounts), senders, connections)
+
 	var wg sync.WaitGroup
-	var wgReceiver sync.WaitGroup
+	var wgTracker sync.WaitGroup
 
-	chTemp := make(chan *statistics.TestResult, len(accounts)*1000)
-	chStatistics := make(chan *statistics.TestResult)
+	txs := eth.NewInFlight()
+	chain := statistics.NewChainStats()
 
-	// 建立连接
-	works := make([]*eth.Client, len(accounts))
-	for i := 0; i < len(works); i++ {
-		client, err := eth.NewClient(i, wsURL, rpcAddr, accounts[i], recipientAddr)
-		if err != nil {
+	// metrics of senders, for the time series, and prometheus if enabled
+	metrics := statistics.NewMetrics()
+	metrics.SetTargetTPS(float64(senders * eth.BatchSize))
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		go func() {
+			log.Printf("metrics listen on %s", *metricsAddr)
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Fatalf("Failed to serve metrics: %v", err)
+			}
+		}()
+	}
+
+	// 建立连接, senders share the connections round robin
+	conns := make([]*eth.Conn, connections)
+	for i := range conns {
+		if conns[i], err = eth.DialConn(wsURL); err != nil {
 			log.Fatal("Failed to connect to WebSocket:", err)
 		}
-		works[i] = client
+		defer conns[i].Close()
+	}
+	works := make([]*eth.Sender, senders)
+	for i := range works {
+		var own []*eth.Account
+		for j := i; j < len(accounts); j += senders {
+			own = append(own, accounts[j])
+		}
+		works[i] = eth.NewSender(i, conns[i%connections], rpcAddr, own, recipientAddr)
+		works[i].Metrics = metrics
 	}
 
-	// query time
-	go func() {
-		client, _ := eth.NewClient(0, wsURL, rpcAddr, accounts[0], recipientAddr)
-		client.QueryTxTime(chTemp, chStatistics)
-		log.Printf("query time done")
-	}()
+	// 资金准备
+	var faucet *eth.Account
+	if faucetHex != "" {
+		amount, err := parseEther(*fundAmount)
+		if err != nil {
+			log.Fatalf("Invalid -fund %s: %v", *fundAmount, err)
+		}
+		if faucet, err = eth.NewAccount(-1, faucetHex); err != nil {
+			log.Fatal("Invalid -faucet: ", err)
+		}
+		addresses := make([]common.Address, len(accounts))
+		for i, account := range accounts {
+			addresses[i] = account.Address()
+		}
+		if err := faucet.Fund(conns[0], addresses, amount); err != nil {
+			log.Fatalf("Failed to fund accounts: %v", err)
+		}
+	} else if *sweep {
+		log.Fatal("-sweep requires -faucet")
+	}
+
+	// test window of the chain observer
+	startBlock, err := conns[0].BlockNumber()
+	if err != nil {
+		log.Fatal("Failed to get block number:", err)
+	}
 
 	// statistics
-	wgReceiver.Add(1)
+	log.Printf("statistics start...")
+	collector := statistics.NewCollector(uint64(len(works)), chain, statistics.Options{
+		JSONPath:     *resultJSON,
+		CSVPath:      *resultCSV,
+		TracePath:    *traceLog,
+		HTMLPath:     *htmlReport,
+		Metrics:      metrics,
+		OTLPPath:     *otlpFile,
+		OTLPEndpoint: *otlpEndpoint,
+		TUI:          *tui,
+		Config: config{
+			WsURL:         wsURL,
+			RpcAddr:       rpcAddr,
+			Accounts:      len(senderKeys),
+			KeySource:     keySource,
+			HDPath:        hdPathOf(keySource),
+			Senders:       senders,
+			Connections:   connections,
+			Fund:          fundOf(faucetHex),
+			MaxPending:    maxPending,
+			PressDuration: PressDuration.String(),
+			Confirmations: *confirmations,
+			FinalityTag:   *finalityTag,
+			TxTimeout:     txTimeout.String(),
+		},
+	})
+
+	// track confirmation by newHeads
+	tracker, err := eth.NewClient(wsURL)
+	if err != nil {
+		log.Fatal("Failed to connect to WebSocket:", err)
+	}
+	tracker.Metrics = metrics
+	wgTracker.Add(1)
 	go func() {
-		defer wgReceiver.Done()
-		log.Printf("statistics start...")
-		statistics.HandleStatistics(uint64(len(works)), chStatistics)
+		defer wgTracker.Done()
+		tracker.TrackTxs(txs, collector, chain, eth.TrackOptions{Confirmations: *confirmations, FinalityTag: *finalityTag, Timeout: *txTimeout})
+		log.Printf("track txs done")
 	}()
 
 	for i := 0; i < len(works); i++ {
@@ -65,19 +226,77 @@ func main() {
 		}
 
 		wg.Add(1)
-		log.Printf("worker %d start...", i)
-		go func(index int, ch chan *statistics.TestResult) {
+		log.Printf("sender %d start...", i)
+		go func(index int) {
 			defer wg.Done()
 
-			err := works[i].BatchSendTxs(PressDuration, maxPending, ch)
+			err := works[index].SendTxs(PressDuration, maxPending, txs)
 			if err != nil {
-				log.Printf("worker %d failed: %v", index, err)
+				log.Printf("sender %d failed: %v", index, err)
 				return
 			}
-		}(i, chTemp)
+		}(i)
 	}
 	wg.Wait()
-	close(chTemp)
-	close(chStatistics)
-	wgReceiver.Wait()
+	endBlock, endErr := conns[0].BlockNumber()
+	txs.Close()
+	wgTracker.Wait()
+	// walks blocks of the test window, not the tail of the tracking
+	if endErr != nil {
+		log.Printf("Failed to get block number: %v", endErr)
+	} else if observer, err := eth.NewClient(wsURL); err != nil {
+		log.Printf("Failed to connect to WebSocket: %v", err)
+	} else {
+		if err := observer.ObserveChain(startBlock, endBlock, chain); err != nil {
+			log.Printf("Failed to observe chain: %v", err)
+		}
+		_ = observer.Close()
+	}
+	collector.Close()
+
+	if *sweep {
+		var wgSweep sync.WaitGroup
+		for i, account := range accounts {
+			wgSweep.Add(1)
+			go func(account *eth.Account, conn *eth.Conn) {
+				defer wgSweep.Done()
+				if err := account.Sweep(conn, faucet.Address()); err != nil {
+					log.Printf("Failed to sweep %s: %v", account.Address().Hex(), err)
+				}
+			}(account, conns[i%connections])
+		}
+		wgSweep.Wait()
+		log.Printf("swept %d accounts back to %s", len(accounts), faucet.Address().Hex())
+	}
+}
+
+// parseEther decimal ether to wei
+func parseEther(ether string) (*big.Int, error) {
+	f, ok := new(big.Float).SetPrec(256).SetString(ether)
+	if !ok || f.Sign() < 0 {
+		return nil, fmt.Errorf("not a positive number")
+	}
+	wei, _ := f.Mul(f, big.NewFloat(params.Ether)).Int(nil)
+	return wei, nil
+}
+
+// topologyOf senders and connections for n accounts, a sender owns at least one account
+// and a connection serves at least one sender
+func topologyOf(n int) (senders, connections int) {
+	senders = *numSenders
+	if senders <= 0 || senders > n {
+		senders = n
+	}
+	connections = *numConnections
+	if connections <= 0 || connections > senders {
+		connections = senders
+	}
+	return senders, connections
+}
+
+func fundOf(faucetHex string) string {
+	if faucetHex != "" {
+		return *fundAmount
+	}
+	return ""
 }